@@ -3,54 +3,330 @@ package apod
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"image"
 	"io"
 	"log/slog"
-	"net/http"
+	"sync"
 	"time"
 
 	"github.com/kamaln7/resolvable"
+	"github.com/nfnt/resize"
 	"github.com/peteretelej/nasa"
 )
 
-var Today = resolvable.New(
-	(&apod{}).getAPOD,
-	resolvable.WithRetry(),
-	resolvable.WithGraceful(),
-	resolvable.WithCacheTTL(time.Minute),
-).WithBackgroundContext()
+// PreviewSize bounds the longest side of ImagePreview, which is plenty for
+// rendering to a terminal.
+const PreviewSize = 1024
+
+// HDSize bounds the longest side of ImageHD. Beyond it, decoded images take
+// more memory than any terminal has pixels to show them.
+const HDSize = 4096
+
+// MaxHDBytes caps HD downloads; a few APODs are hundreds of megabytes.
+const MaxHDBytes = 40 << 20
+
+// MaxPixels caps the images that are decoded. A well compressed image can be
+// small to download and still take gigabytes once decoded.
+const MaxPixels = 50_000_000
+
+// ArchiveStart is the date of the first APOD in NASA's archive.
+var ArchiveStart = time.Date(1995, time.June, 16, 0, 0, 0, 0, time.UTC)
+
+var (
+	ErrBeforeArchive = errors.New("date is before the APOD archive start")
+	ErrFutureDate    = errors.New("date is in the future")
+	ErrNoImage       = errors.New("no image URL found")
+	ErrTooLarge      = errors.New("image is too large")
+)
+
+// Default is the archive backed by NASA's API that the package-level
+// functions use.
+var Default = New(NASA{})
+
+var Today = Default.Today
+
+// ForDate returns the APOD for the given day from the Default archive.
+func ForDate(ctx context.Context, date time.Time) (*APOD, error) {
+	return Default.ForDate(ctx, date)
+}
+
+// Random returns an APOD from a random day, from the Default archive.
+func Random(ctx context.Context) (*APOD, error) {
+	return Default.Random(ctx)
+}
+
+// UseDiskCache makes the Default archive consult c before its source.
+func UseDiskCache(c *DiskCache) {
+	Default.UseDiskCache(c)
+}
+
+// Metadata is an APOD API response. It extends nasa.Image with the fields
+// that package doesn't decode.
+type Metadata struct {
+	nasa.Image
+
+	MediaType    string `json:"media_type"`
+	ThumbnailURL string `json:"thumbnail_url,omitempty"`
+	Copyright    string `json:"copyright,omitempty"`
+}
+
+// IsVideo reports whether the APOD is a video rather than an image.
+func (m *Metadata) IsVideo() bool {
+	return m.MediaType == "video"
+}
+
+// HasImage reports whether there is a still image to show: either the APOD
+// itself or a video's thumbnail.
+func (m *Metadata) HasImage() bool {
+	return m.imageURL() != ""
+}
+
+func (m *Metadata) imageURL() string {
+	if m.IsVideo() {
+		return m.ThumbnailURL
+	}
+	if m.URL != "" {
+		return m.URL
+	}
+	return m.HDURL
+}
+
+// HasHD reports whether there is a high resolution version of the image.
+// Sometimes it is the same file as the standard one.
+func (m *Metadata) HasHD() bool {
+	return !m.IsVideo() && m.HDURL != ""
+}
 
 type APOD struct {
-	*nasa.Image
+	*Metadata
 
 	ImageBytes   resolvable.V[[]byte]
 	ImageDecoded resolvable.V[image.Image]
+	// ImagePreview is ImageDecoded downscaled to fit PreviewSize, so that
+	// repeated conversions don't have to walk the full-resolution image.
+	ImagePreview resolvable.V[image.Image]
+	// HDBytes is the HD image as downloaded, separately from the others and
+	// only if it is no bigger than MaxHDBytes. See ImageHD.
+	HDBytes resolvable.V[[]byte]
+
+	// ImageProgress and HDProgress follow the downloads behind ImageBytes
+	// and HDBytes.
+	ImageProgress Progress
+	HDProgress    Progress
+
+	source Source
+	disk   *DiskCache
 }
 
-type apod struct {
+// Archive fetches APODs from a Source and caches them per day.
+type Archive struct {
+	// Today resolves the latest APOD, refreshing it at most once a minute.
+	Today resolvable.V[*APOD]
+
+	source      Source
 	lastAPOD    *APOD
 	lastAPODDay time.Time
+
+	mu    sync.Mutex // protects the following
+	dates map[string]*day
+	uses  uint64 // counts lookups, to order days by when they were used
+	disk  *DiskCache
+
+	randomMu sync.Mutex  // protects random, and serializes refilling it
+	random   []*Metadata // random picks fetched ahead, see Random
 }
 
-func (n *apod) getAPOD(_ context.Context) (*APOD, error) {
+// maxDays is how many days the archive keeps in memory, images and all. The
+// least recently used ones are dropped beyond that, and are fetched again,
+// or read from the disk cache, when they are next looked up.
+const maxDays = 32
+
+// day is an entry in the per-day cache.
+type day struct {
+	get  resolvable.Ctx[*APOD]
+	used uint64 // Archive.uses when it was last looked up
+}
+
+// randomBatch is how many random APODs are asked for at once, so that not
+// every pick costs a request against the API's rate limit.
+const randomBatch = 10
+
+// New returns an archive that fetches from src.
+func New(src Source) *Archive {
+	n := &Archive{
+		source: src,
+		dates:  map[string]*day{},
+	}
+	n.Today = resolvable.New(
+		n.getAPOD,
+		resolvable.WithRetry(),
+		resolvable.WithGraceful(),
+		resolvable.WithCacheTTL(time.Minute),
+	).WithBackgroundContext()
+	return n
+}
+
+// UseDiskCache makes the archive consult c before hitting its source, and
+// store whatever it fetches in it.
+func (n *Archive) UseDiskCache(c *DiskCache) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.disk = c
+}
+
+func (n *Archive) getAPOD(ctx context.Context) (*APOD, error) {
 	if n.lastAPODDay == today() {
 		return n.lastAPOD, nil
 	}
 
-	slog.Info("fetching APOD", "day", today())
-	apod, err := nasa.APODToday()
+	apod, err := n.fetch(ctx, today(), time.Time{})
 	if err != nil {
 		return nil, err
 	}
-	n.lastAPOD = newAPOD(apod)
+	n.lastAPOD = apod
 	n.lastAPODDay = today()
+	n.store(n.lastAPOD)
 	return n.lastAPOD, nil
 }
 
-func newAPOD(apod *nasa.Image) *APOD {
+// ForDate returns the APOD for the given day. The last maxDays days looked
+// up are cached, so repeated lookups share the same *APOD and its lazily
+// fetched image.
+func (n *Archive) ForDate(ctx context.Context, date time.Time) (*APOD, error) {
+	date = Day(date)
+	if date.Before(ArchiveStart) {
+		return nil, fmt.Errorf("%s: %w", date.Format(time.DateOnly), ErrBeforeArchive)
+	}
+	if date.After(today()) {
+		return nil, fmt.Errorf("%s: %w", date.Format(time.DateOnly), ErrFutureDate)
+	}
+
+	n.mu.Lock()
+	d, ok := n.dates[date.Format(time.DateOnly)]
+	if ok {
+		n.uses++
+		d.used = n.uses
+	} else {
+		d = n.put(date.Format(time.DateOnly), resolvable.New(func(ctx context.Context) (*APOD, error) {
+			return n.fetch(ctx, date, date)
+		}, resolvable.WithRetry()))
+	}
+	n.mu.Unlock()
+
+	return d.get(ctx)
+}
+
+// Random returns an APOD from a random day of the archive. It is cached per
+// day like any other, so it can be looked up again with ForDate.
+func (n *Archive) Random(ctx context.Context) (*APOD, error) {
+	n.randomMu.Lock()
+	if len(n.random) == 0 {
+		slog.Info("fetching random APODs", "count", randomBatch)
+		ms, err := n.source.Random(ctx, randomBatch)
+		if err != nil {
+			n.randomMu.Unlock()
+			return nil, err
+		}
+		n.random = ms
+	}
+	if len(n.random) == 0 {
+		n.randomMu.Unlock()
+		return nil, ErrNotFound
+	}
+	m := n.random[0]
+	n.random = n.random[1:]
+	n.randomMu.Unlock()
+
+	n.mu.Lock()
+	disk := n.disk
+	key := m.ApodDate.Format(time.DateOnly)
+	if _, ok := n.dates[key]; !ok {
+		n.put(key, resolvable.Static(newAPOD(m, n.source, disk)))
+	}
+	n.mu.Unlock()
+	if disk != nil && !disk.Has(m.ApodDate) {
+		if err := disk.PutMetadata(m); err != nil {
+			slog.Warn("failed to cache APOD metadata", "day", m.ApodDate, "error", err)
+		}
+	}
+	return n.ForDate(ctx, m.ApodDate)
+}
+
+// Cached reports whether the APOD for date has been fetched already, in
+// memory or on disk, so that looking it up won't wait on the source.
+func (n *Archive) Cached(date time.Time) bool {
+	date = Day(date)
+	n.mu.Lock()
+	_, ok := n.dates[date.Format(time.DateOnly)]
+	disk := n.disk
+	n.mu.Unlock()
+	return ok || disk != nil && disk.Has(date)
+}
+
+// fetch returns the APOD for date from the disk cache if present, and
+// otherwise asks the source for query (zero for the latest) and persists the
+// result.
+func (n *Archive) fetch(ctx context.Context, date, query time.Time) (*APOD, error) {
+	n.mu.Lock()
+	disk := n.disk
+	n.mu.Unlock()
+
+	if disk != nil {
+		if img, ok := disk.Metadata(date); ok {
+			return newAPOD(img, n.source, disk), nil
+		}
+	}
+
+	slog.Info("fetching APOD", "day", date)
+	img, err := n.source.Metadata(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	if disk != nil {
+		if err := disk.PutMetadata(img); err != nil {
+			slog.Warn("failed to cache APOD metadata", "day", date, "error", err)
+		}
+	}
+	return newAPOD(img, n.source, disk), nil
+}
+
+// store seeds the per-day cache with an already fetched APOD.
+func (n *Archive) store(a *APOD) {
+	if a.ApodDate.IsZero() {
+		return
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.put(a.ApodDate.Format(time.DateOnly), resolvable.Static(a))
+}
+
+// put caches get as the day for key, dropping the least recently used day if
+// that makes too many. n.mu must be held.
+func (n *Archive) put(key string, get resolvable.Ctx[*APOD]) *day {
+	n.uses++
+	d := &day{get: get, used: n.uses}
+	n.dates[key] = d
+	if len(n.dates) > maxDays {
+		var oldest string
+		for k, d := range n.dates {
+			if oldest == "" || d.used < n.dates[oldest].used {
+				oldest = k
+			}
+		}
+		delete(n.dates, oldest)
+	}
+	return d
+}
+
+func newAPOD(apod *Metadata, source Source, disk *DiskCache) *APOD {
 	a := &APOD{
-		Image: apod,
+		Metadata: apod,
+		source:   source,
+	}
+	if !apod.ApodDate.IsZero() {
+		a.disk = disk
 	}
 	a.ImageBytes = resolvable.New(a.getImageBytes,
 		resolvable.WithRetry(),
@@ -60,39 +336,68 @@ func newAPOD(apod *nasa.Image) *APOD {
 		resolvable.WithRetry(),
 		resolvable.WithGraceful(),
 	).WithBackgroundContext()
+	a.ImagePreview = resolvable.New(a.getImagePreview,
+		resolvable.WithRetry(),
+		resolvable.WithGraceful(),
+	).WithBackgroundContext()
+	a.HDBytes = resolvable.New(a.getHDBytes,
+		resolvable.WithRetry(),
+		resolvable.WithGraceful(),
+	).WithBackgroundContext()
 	return a
 }
 
 func (a *APOD) getImageBytes(ctx context.Context) ([]byte, error) {
-	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
-	defer cancel()
-
-	var url string
-	if a.URL != "" {
-		url = a.URL
-	} else if a.HDURL != "" {
-		url = a.HDURL
-	} else {
-		return nil, fmt.Errorf("no image URL found")
+	if a.disk != nil {
+		if byt, ok := a.disk.Image(a.ApodDate); ok {
+			return byt, nil
+		}
+	}
+
+	url := a.imageURL()
+	if url == "" {
+		return nil, ErrNoImage
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	body, err := a.download(ctx, url, 0, &a.ImageProgress)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := http.DefaultClient.Do(req)
+	if a.disk != nil {
+		if err := a.disk.PutImage(a.ApodDate, body); err != nil {
+			slog.Warn("failed to cache APOD image", "day", a.ApodDate, "error", err)
+		}
+	}
+
+	return body, nil
+}
+
+// download reads the image at url from the source into progress, refusing
+// anything bigger than maxBytes unless it is 0.
+func (a *APOD) download(ctx context.Context, url string, maxBytes int64, progress *Progress) ([]byte, error) {
+	r, size, err := a.source.Image(ctx, url)
 	if err != nil {
-		return nil, fmt.Errorf("downloading image: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
+	defer r.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	if maxBytes > 0 && size > maxBytes {
+		return nil, fmt.Errorf("%d bytes: %w", size, ErrTooLarge)
+	}
+	progress.start(size)
+	var body io.Reader = progressReader{Reader: r, progress: progress}
+	if maxBytes > 0 {
+		body = io.LimitReader(body, maxBytes+1)
+	}
+	byt, err := io.ReadAll(body)
 	if err != nil {
 		return nil, fmt.Errorf("reading image body: %w", err)
 	}
-
-	return body, nil
+	if maxBytes > 0 && int64(len(byt)) > maxBytes {
+		return nil, fmt.Errorf("over %d bytes: %w", maxBytes, ErrTooLarge)
+	}
+	return byt, nil
 }
 
 func (a *APOD) getImageDecoded(ctx context.Context) (image.Image, error) {
@@ -100,13 +405,89 @@ func (a *APOD) getImageDecoded(ctx context.Context) (image.Image, error) {
 	if err != nil {
 		return nil, err
 	}
-	img, _, err := image.Decode(bytes.NewReader(byt))
+	img, err := decodeImage(byt)
 	if err != nil {
 		return nil, fmt.Errorf("decoding image: %w", err)
 	}
 	return img, nil
 }
 
+// decodeImage decodes byt, checking its size first so that an image over
+// MaxPixels isn't allocated.
+func decodeImage(byt []byte) (image.Image, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(byt))
+	if err != nil {
+		return nil, err
+	}
+	if pixels := int64(cfg.Width) * int64(cfg.Height); pixels > MaxPixels {
+		return nil, fmt.Errorf("%dx%d pixels: %w", cfg.Width, cfg.Height, ErrTooLarge)
+	}
+	img, _, err := image.Decode(bytes.NewReader(byt))
+	return img, err
+}
+
+// getImagePreview decodes the image on its own rather than through
+// ImageDecoded, so that the full resolution image isn't kept around along
+// with the preview.
+func (a *APOD) getImagePreview(ctx context.Context) (image.Image, error) {
+	img, err := a.getImageDecoded(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return resize.Thumbnail(PreviewSize, PreviewSize, img, resize.Lanczos3), nil
+}
+
+// ImageHD decodes HDBytes and downscales it to fit HDSize. Unlike the other
+// decoded images it isn't kept, since it is only needed while shown and
+// takes a lot more memory than the bytes it is decoded from.
+func (a *APOD) ImageHD() (image.Image, error) {
+	byt, err := a.HDBytes()
+	if err != nil {
+		return nil, err
+	}
+	img, err := decodeImage(byt)
+	if err != nil {
+		return nil, fmt.Errorf("decoding HD image: %w", err)
+	}
+	return resize.Thumbnail(HDSize, HDSize, img, resize.Lanczos3), nil
+}
+
+func (a *APOD) getHDBytes(ctx context.Context) ([]byte, error) {
+	if !a.HasHD() {
+		return nil, ErrNoImage
+	}
+
+	var byt []byte
+	switch {
+	case a.HDURL == a.imageURL():
+		// already downloaded as the standard image
+		var err error
+		if byt, err = a.ImageBytes(); err != nil {
+			return nil, err
+		}
+	case a.disk != nil:
+		byt, _ = a.disk.HDImage(a.ApodDate)
+	}
+	if byt == nil {
+		var err error
+		if byt, err = a.download(ctx, a.HDURL, MaxHDBytes, &a.HDProgress); err != nil {
+			return nil, err
+		}
+		if a.disk != nil {
+			if err := a.disk.PutHDImage(a.ApodDate, byt); err != nil {
+				slog.Warn("failed to cache APOD HD image", "day", a.ApodDate, "error", err)
+			}
+		}
+	}
+	return byt, nil
+}
+
 func today() time.Time {
-	return time.Now().Truncate(time.Hour * 24)
+	return Day(time.Now())
+}
+
+// Day truncates t to midnight UTC of its calendar day, which is how APOD
+// dates are keyed.
+func Day(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
 }
@@ -0,0 +1,313 @@
+package apod
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/peteretelej/nasa"
+)
+
+// writeFixture saves an APOD for day in dir the way Fixtures reads them.
+func writeFixture(t *testing.T, dir string, day time.Time, m Metadata) {
+	t.Helper()
+	m.Date = day.Format(time.DateOnly)
+	if m.Title == "" {
+		m.Title = "APOD " + m.Date
+	}
+	if m.MediaType == "" {
+		m.MediaType = "image"
+	}
+	byt, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, m.Date+".json"), byt, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// writePNG saves a w x h image as name in dir.
+func writePNG(t *testing.T, dir, name string, w, h int) {
+	t.Helper()
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	for i := range img.Pix {
+		img.Pix[i] = uint8(i)
+	}
+	f, err := os.Create(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := png.Encode(f, img); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func mustDay(t *testing.T, s string) time.Time {
+	t.Helper()
+	d, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestForDate(t *testing.T) {
+	dir := t.TempDir()
+	writeFixture(t, dir, mustDay(t, "2024-01-02"), Metadata{})
+	archive := New(Fixtures(dir))
+	ctx := context.Background()
+
+	a, err := archive.ForDate(ctx, mustDay(t, "2024-01-02").Add(13*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Title != "APOD 2024-01-02" || !a.ApodDate.Equal(mustDay(t, "2024-01-02")) {
+		t.Errorf("got %q for %s, want the fixture for 2024-01-02", a.Title, a.ApodDate)
+	}
+	again, err := archive.ForDate(ctx, mustDay(t, "2024-01-02"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != a {
+		t.Error("a second lookup of the day didn't share the cached APOD")
+	}
+	if !archive.Cached(mustDay(t, "2024-01-02")) {
+		t.Error("the day looked up isn't reported as cached")
+	}
+}
+
+func TestForDateErrors(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "2024-01-03.json"), []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	archive := New(Fixtures(dir))
+	ctx := context.Background()
+
+	tests := []struct {
+		date string
+		want error
+	}{
+		{"2024-01-01", ErrNotFound},
+		{"1995-06-15", ErrBeforeArchive},
+		{time.Now().AddDate(0, 0, 2).Format(time.DateOnly), ErrFutureDate},
+	}
+	for _, tt := range tests {
+		a, err := archive.ForDate(ctx, mustDay(t, tt.date))
+		if !errors.Is(err, tt.want) || a != nil {
+			t.Errorf("ForDate(%s) = %v, %v; want %v", tt.date, a, err, tt.want)
+		}
+	}
+
+	a, err := archive.ForDate(ctx, mustDay(t, "2024-01-03"))
+	if err == nil || errors.Is(err, ErrNotFound) || a != nil {
+		t.Errorf("ForDate of a corrupt fixture = %v, %v; want a decoding error", a, err)
+	}
+}
+
+func TestToday(t *testing.T) {
+	dir := t.TempDir()
+	writeFixture(t, dir, mustDay(t, "2024-01-01"), Metadata{})
+	writeFixture(t, dir, mustDay(t, "2024-01-04"), Metadata{})
+	archive := New(Fixtures(dir))
+
+	a, err := archive.Today()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !a.ApodDate.Equal(mustDay(t, "2024-01-04")) {
+		t.Errorf("Today() = %s, want the latest fixture", a.ApodDate)
+	}
+}
+
+func TestTodayEmpty(t *testing.T) {
+	archive := New(Fixtures(t.TempDir()))
+	a, err := archive.Today()
+	if !errors.Is(err, ErrNotFound) || a != nil {
+		t.Errorf("Today() = %v, %v; want %v", a, err, ErrNotFound)
+	}
+}
+
+func TestForDateEvicts(t *testing.T) {
+	dir := t.TempDir()
+	first := mustDay(t, "2024-01-01")
+	for i := range maxDays + 1 {
+		writeFixture(t, dir, first.AddDate(0, 0, i), Metadata{})
+	}
+	archive := New(Fixtures(dir))
+	ctx := context.Background()
+
+	a, err := archive.ForDate(ctx, first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= maxDays; i++ {
+		if _, err := archive.ForDate(ctx, first.AddDate(0, 0, i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(archive.dates) != maxDays {
+		t.Errorf("%d days cached, want %d", len(archive.dates), maxDays)
+	}
+	if archive.Cached(first) {
+		t.Error("the least recently used day is still cached")
+	}
+	again, err := archive.ForDate(ctx, first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again == a {
+		t.Error("the evicted day came back as the same APOD")
+	}
+}
+
+func TestImagePreview(t *testing.T) {
+	dir := t.TempDir()
+	writeFixture(t, dir, mustDay(t, "2024-01-02"), Metadata{Image: nasa.Image{URL: "https://example.com/big.png"}})
+	writePNG(t, dir, "big.png", 2*PreviewSize, PreviewSize/2)
+	archive := New(Fixtures(dir))
+
+	a, err := archive.ForDate(context.Background(), mustDay(t, "2024-01-02"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := a.ImagePreview()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := img.Bounds().Size(), image.Pt(PreviewSize, PreviewSize/4); got != want {
+		t.Errorf("preview is %v, want %v", got, want)
+	}
+	if read, total := a.ImageProgress.Bytes(); read != total || total <= 0 {
+		t.Errorf("progress is %d of %d bytes, want all of them", read, total)
+	}
+}
+
+func TestImageMissing(t *testing.T) {
+	dir := t.TempDir()
+	writeFixture(t, dir, mustDay(t, "2024-01-02"), Metadata{Image: nasa.Image{URL: "https://example.com/missing.png"}})
+	archive := New(Fixtures(dir))
+
+	a, err := archive.ForDate(context.Background(), mustDay(t, "2024-01-02"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img, err := a.ImagePreview(); !errors.Is(err, os.ErrNotExist) || img != nil {
+		t.Errorf("ImagePreview() = %v, %v; want %v", img, err, os.ErrNotExist)
+	}
+}
+
+func TestImageHDKeepsBytes(t *testing.T) {
+	dir := t.TempDir()
+	writeFixture(t, dir, mustDay(t, "2024-01-02"), Metadata{Image: nasa.Image{URL: "https://example.com/small.png", HDURL: "https://example.com/hd.png"}})
+	writePNG(t, dir, "hd.png", 64, 32)
+	archive := New(Fixtures(dir))
+
+	a, err := archive.ForDate(context.Background(), mustDay(t, "2024-01-02"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range 2 {
+		img, err := a.ImageHD()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := img.Bounds().Size(), image.Pt(64, 32); got != want {
+			t.Errorf("HD image is %v, want %v", got, want)
+		}
+		// the second time around it can't be downloaded again
+		if i == 0 {
+			if err := os.Remove(filepath.Join(dir, "hd.png")); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+}
+
+func TestImageTooManyPixels(t *testing.T) {
+	dir := t.TempDir()
+	writeFixture(t, dir, mustDay(t, "2024-01-02"), Metadata{Image: nasa.Image{URL: "https://example.com/huge.png", HDURL: "https://example.com/huge.png"}})
+	// only the header of a 100000x100000 PNG, which is all it takes to know
+	// not to decode the rest
+	ihdr := binary.BigEndian.AppendUint32(nil, 100000)
+	ihdr = binary.BigEndian.AppendUint32(ihdr, 100000)
+	ihdr = append([]byte("IHDR"), append(ihdr, 8, 0, 0, 0, 0)...)
+	byt := binary.BigEndian.AppendUint32([]byte("\x89PNG\r\n\x1a\n"), uint32(len(ihdr)-4))
+	byt = binary.BigEndian.AppendUint32(append(byt, ihdr...), crc32.ChecksumIEEE(ihdr))
+	if err := os.WriteFile(filepath.Join(dir, "huge.png"), byt, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	archive := New(Fixtures(dir))
+
+	a, err := archive.ForDate(context.Background(), mustDay(t, "2024-01-02"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img, err := a.ImagePreview(); !errors.Is(err, ErrTooLarge) || img != nil {
+		t.Errorf("ImagePreview() = %v, %v; want %v", img, err, ErrTooLarge)
+	}
+	if img, err := a.ImageHD(); !errors.Is(err, ErrTooLarge) || img != nil {
+		t.Errorf("ImageHD() = %v, %v; want %v", img, err, ErrTooLarge)
+	}
+}
+
+func TestRandom(t *testing.T) {
+	dir := t.TempDir()
+	days := map[time.Time]bool{}
+	for i := range 3 {
+		d := mustDay(t, "2024-01-01").AddDate(0, 0, i)
+		writeFixture(t, dir, d, Metadata{})
+		days[d] = true
+	}
+	disk, err := NewDiskCache(t.TempDir(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive := New(Fixtures(dir))
+	archive.UseDiskCache(disk)
+	ctx := context.Background()
+
+	seen := map[time.Time]bool{}
+	for range 3 {
+		a, err := archive.Random(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !days[a.ApodDate] {
+			t.Fatalf("Random() picked %s, which has no fixture", a.ApodDate)
+		}
+		seen[a.ApodDate] = true
+
+		same, err := archive.ForDate(ctx, a.ApodDate)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if same != a {
+			t.Errorf("ForDate(%s) didn't return the random pick", a.ApodDate)
+		}
+		if !disk.Has(a.ApodDate) {
+			t.Errorf("the random pick for %s wasn't written to disk", a.ApodDate)
+		}
+	}
+	// the batch is a shuffle of all three days
+	if len(seen) != len(days) {
+		t.Errorf("picked %d different days of %d", len(seen), len(days))
+	}
+}
+
+func TestRandomEmpty(t *testing.T) {
+	archive := New(Fixtures(t.TempDir()))
+	if a, err := archive.Random(context.Background()); !errors.Is(err, ErrNotFound) || a != nil {
+		t.Errorf("Random() = %v, %v; want %v", a, err, ErrNotFound)
+	}
+}
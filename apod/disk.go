@@ -0,0 +1,215 @@
+package apod
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	diskMetadataExt = ".json"
+	diskImageExt    = ".img"
+	diskHDExt       = ".hd"
+)
+
+// DiskCache persists APOD metadata and raw image bytes on disk, keyed by day.
+// Once the total size exceeds maxBytes, the least recently used days are
+// evicted.
+type DiskCache struct {
+	dir      string
+	maxBytes int64
+
+	mu sync.Mutex
+}
+
+// NewDiskCache creates dir if needed and returns a cache rooted at it.
+// A maxBytes <= 0 disables eviction.
+func NewDiskCache(dir string, maxBytes int64) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating cache dir: %w", err)
+	}
+	return &DiskCache{dir: dir, maxBytes: maxBytes}, nil
+}
+
+// Metadata returns the cached metadata for date, if any.
+func (c *DiskCache) Metadata(date time.Time) (*Metadata, bool) {
+	byt, ok := c.read(date, diskMetadataExt)
+	if !ok {
+		return nil, false
+	}
+	var img Metadata
+	if err := json.Unmarshal(byt, &img); err != nil {
+		slog.Warn("corrupt APOD metadata in cache", "day", date, "error", err)
+		return nil, false
+	}
+	return &img, true
+}
+
+// Has reports whether the metadata for date is cached, without counting as
+// a use of it.
+func (c *DiskCache) Has(date time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err := os.Stat(c.path(date, diskMetadataExt))
+	return err == nil
+}
+
+// PutMetadata stores the metadata under its own date.
+func (c *DiskCache) PutMetadata(img *Metadata) error {
+	if img.ApodDate.IsZero() {
+		return errors.New("metadata has no date")
+	}
+	byt, err := json.Marshal(img)
+	if err != nil {
+		return err
+	}
+	return c.write(img.ApodDate, diskMetadataExt, byt)
+}
+
+// Image returns the cached image bytes for date, if any.
+func (c *DiskCache) Image(date time.Time) ([]byte, bool) {
+	return c.read(date, diskImageExt)
+}
+
+// PutImage stores the raw image bytes for date.
+func (c *DiskCache) PutImage(date time.Time, byt []byte) error {
+	return c.write(date, diskImageExt, byt)
+}
+
+// HDImage returns the cached HD image bytes for date, if any.
+func (c *DiskCache) HDImage(date time.Time) ([]byte, bool) {
+	return c.read(date, diskHDExt)
+}
+
+// PutHDImage stores the raw HD image bytes for date.
+func (c *DiskCache) PutHDImage(date time.Time, byt []byte) error {
+	return c.write(date, diskHDExt, byt)
+}
+
+func (c *DiskCache) path(date time.Time, ext string) string {
+	return filepath.Join(c.dir, Day(date).Format(time.DateOnly)+ext)
+}
+
+func (c *DiskCache) read(date time.Time, ext string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	path := c.path(date, ext)
+	byt, err := os.ReadFile(path)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			slog.Warn("reading APOD cache", "path", path, "error", err)
+		}
+		return nil, false
+	}
+	// bump the mtime so eviction treats this day as recently used
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+	return byt, true
+}
+
+func (c *DiskCache) write(date time.Time, ext string, byt []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	path := c.path(date, ext)
+	tmp, err := os.CreateTemp(c.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(byt); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+
+	c.evict(Day(date).Format(time.DateOnly))
+	return nil
+}
+
+// evict removes the least recently used days until the cache fits in
+// maxBytes. The day that was just written is kept regardless.
+func (c *DiskCache) evict(keep string) {
+	if c.maxBytes <= 0 {
+		return
+	}
+
+	type entry struct {
+		day   string
+		size  int64
+		used  time.Time
+		paths []string
+	}
+	entries := map[string]*entry{}
+	var total int64
+
+	files, err := os.ReadDir(c.dir)
+	if err != nil {
+		slog.Warn("listing APOD cache", "dir", c.dir, "error", err)
+		return
+	}
+	for _, f := range files {
+		name := f.Name()
+		ext := filepath.Ext(name)
+		if f.IsDir() || !slices.Contains([]string{diskMetadataExt, diskImageExt, diskHDExt}, ext) {
+			continue
+		}
+		info, err := f.Info()
+		if err != nil {
+			continue
+		}
+		day := strings.TrimSuffix(name, ext)
+		e, ok := entries[day]
+		if !ok {
+			e = &entry{day: day}
+			entries[day] = e
+		}
+		e.size += info.Size()
+		e.paths = append(e.paths, filepath.Join(c.dir, name))
+		if info.ModTime().After(e.used) {
+			e.used = info.ModTime()
+		}
+		total += info.Size()
+	}
+
+	if total <= c.maxBytes {
+		return
+	}
+
+	lru := make([]*entry, 0, len(entries))
+	for _, e := range entries {
+		lru = append(lru, e)
+	}
+	slices.SortFunc(lru, func(a, b *entry) int {
+		return a.used.Compare(b.used)
+	})
+	for _, e := range lru {
+		if total <= c.maxBytes {
+			break
+		}
+		if e.day == keep {
+			continue
+		}
+		slog.Info("evicting APOD from cache", "day", e.day, "bytes", e.size)
+		for _, path := range e.paths {
+			if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				slog.Warn("evicting APOD from cache", "path", path, "error", err)
+			}
+		}
+		total -= e.size
+	}
+}
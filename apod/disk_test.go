@@ -0,0 +1,95 @@
+package apod
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/peteretelej/nasa"
+)
+
+func TestDiskCacheRoundTrip(t *testing.T) {
+	c, err := NewDiskCache(t.TempDir(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := mustDay(t, "2024-01-02")
+	if _, ok := c.Metadata(d); ok || c.Has(d) {
+		t.Fatal("an empty cache has metadata")
+	}
+
+	m := &Metadata{Image: nasa.Image{Title: "t", ApodDate: d}, MediaType: "image"}
+	if err := c.PutMetadata(m); err != nil {
+		t.Fatal(err)
+	}
+	got, ok := c.Metadata(d)
+	if !ok || got.Title != "t" || !c.Has(d) {
+		t.Errorf("Metadata(%s) = %v, %v after putting it", d, got, ok)
+	}
+	if err := c.PutMetadata(&Metadata{}); err == nil {
+		t.Error("metadata without a date was stored")
+	}
+
+	if err := c.PutImage(d, []byte("img")); err != nil {
+		t.Fatal(err)
+	}
+	if byt, ok := c.Image(d); !ok || !bytes.Equal(byt, []byte("img")) {
+		t.Errorf("Image(%s) = %q, %v", d, byt, ok)
+	}
+	if _, ok := c.HDImage(d); ok {
+		t.Error("the image came back as the HD image too")
+	}
+}
+
+func TestDiskCacheEvicts(t *testing.T) {
+	dir := t.TempDir()
+	c, err := NewDiskCache(dir, 250)
+	if err != nil {
+		t.Fatal(err)
+	}
+	day1, day2, day3 := mustDay(t, "2024-01-01"), mustDay(t, "2024-01-02"), mustDay(t, "2024-01-03")
+	img := bytes.Repeat([]byte{1}, 100)
+	for _, d := range []time.Time{day1, day2} {
+		if err := c.PutImage(d, img); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// day1 is the older of the two, until it is read again
+	old := time.Now().Add(-time.Hour)
+	for i, d := range []time.Time{day1, day2} {
+		used := old.Add(time.Duration(i) * time.Minute)
+		if err := os.Chtimes(c.path(d, diskImageExt), used, used); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, ok := c.Image(day1); !ok {
+		t.Fatal("day1 is missing before anything was evicted")
+	}
+
+	if err := c.PutImage(day3, img); err != nil {
+		t.Fatal(err)
+	}
+	for d, want := range map[time.Time]bool{day1: true, day2: false, day3: true} {
+		_, err := os.Stat(c.path(d, diskImageExt))
+		if got := err == nil; got != want {
+			t.Errorf("%s cached = %v, want %v", d.Format(time.DateOnly), got, want)
+		}
+	}
+}
+
+func TestDiskCacheKeepsLatest(t *testing.T) {
+	dir := t.TempDir()
+	c, err := NewDiskCache(dir, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := mustDay(t, "2024-01-02")
+	if err := c.PutImage(d, bytes.Repeat([]byte{1}, 100)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "2024-01-02"+diskImageExt)); err != nil {
+		t.Error("the day just written was evicted for being over the limit on its own")
+	}
+}
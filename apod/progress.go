@@ -0,0 +1,91 @@
+package apod
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Progress tracks a download as it happens. It is updated by the goroutine
+// doing the download and safe to read from any other.
+type Progress struct {
+	read    atomic.Int64
+	total   atomic.Int64 // -1 while unknown
+	started atomic.Int64 // unix nanoseconds, 0 until the download starts
+
+	mu      sync.Mutex
+	changed chan struct{} // closed on the next update, see Changed
+}
+
+// start resets p for a download of total bytes, or -1 if unknown.
+func (p *Progress) start(total int64) {
+	p.read.Store(0)
+	p.total.Store(total)
+	p.started.Store(time.Now().UnixNano())
+	p.notify()
+}
+
+// Changed returns a channel that is closed the next time the download moves
+// along, so that it can be followed without polling.
+func (p *Progress) Changed() <-chan struct{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.changed == nil {
+		p.changed = make(chan struct{})
+	}
+	return p.changed
+}
+
+func (p *Progress) notify() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.changed != nil {
+		close(p.changed)
+		p.changed = nil
+	}
+}
+
+// Bytes returns how many bytes have been read so far, and how many there are
+// in total, or -1 if that isn't known.
+func (p *Progress) Bytes() (read, total int64) {
+	total = p.total.Load()
+	if p.started.Load() == 0 {
+		total = -1
+	}
+	return p.read.Load(), total
+}
+
+// Started reports whether the download has begun.
+func (p *Progress) Started() bool {
+	return p.started.Load() != 0
+}
+
+// ETA estimates how long the rest of the download will take at the rate it
+// has gone so far. It is false until there is enough to go by.
+func (p *Progress) ETA() (time.Duration, bool) {
+	read, total := p.Bytes()
+	started := p.started.Load()
+	if total <= 0 || read <= 0 || started == 0 {
+		return 0, false
+	}
+	elapsed := time.Since(time.Unix(0, started))
+	if elapsed < time.Second/2 {
+		return 0, false
+	}
+	rate := float64(read) / elapsed.Seconds()
+	return time.Duration(float64(total-read) / rate * float64(time.Second)), true
+}
+
+// progressReader counts the bytes read through it into a Progress.
+type progressReader struct {
+	io.Reader
+	progress *Progress
+}
+
+func (r progressReader) Read(b []byte) (int, error) {
+	n, err := r.Reader.Read(b)
+	r.progress.read.Add(int64(n))
+	r.progress.notify()
+	return n, err
+}
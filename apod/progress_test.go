@@ -0,0 +1,31 @@
+package apod
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestProgressChanged(t *testing.T) {
+	var p Progress
+	changed := p.Changed()
+	if p.Changed() != changed {
+		t.Fatal("Changed() returned a new channel without anything happening")
+	}
+	select {
+	case <-changed:
+		t.Fatal("changed before the download started")
+	default:
+	}
+
+	p.start(5)
+	<-changed
+	changed = p.Changed()
+	if _, err := io.ReadAll(progressReader{Reader: strings.NewReader("hello"), progress: &p}); err != nil {
+		t.Fatal(err)
+	}
+	<-changed
+	if read, total := p.Bytes(); read != 5 || total != 5 {
+		t.Errorf("Bytes() = %d, %d; want 5, 5", read, total)
+	}
+}
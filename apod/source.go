@@ -0,0 +1,294 @@
+package apod
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/peteretelej/nasa"
+)
+
+// ErrNotFound is returned by a Source that has no APOD for a date.
+var ErrNotFound = errors.New("APOD not found")
+
+// StatusError is returned when an image download gets a non-200 response.
+type StatusError struct {
+	Code   int
+	Status string
+}
+
+func (e *StatusError) Error() string {
+	return "unexpected status " + e.Status
+}
+
+// Source provides APOD metadata and images.
+type Source interface {
+	// Metadata returns the APOD for date, or the latest one if date is zero.
+	Metadata(ctx context.Context, date time.Time) (*Metadata, error)
+	// Random returns up to count APODs from random days of the archive.
+	Random(ctx context.Context, count int) ([]*Metadata, error)
+	// Image opens the image at url, as found in the metadata, along with its
+	// size in bytes, or -1 if that isn't known up front. Closing the image
+	// releases whatever the download holds on to.
+	Image(ctx context.Context, url string) (io.ReadCloser, int64, error)
+}
+
+// NASA fetches from NASA's APOD API, authenticating with the NASAKEY
+// environment variable.
+type NASA struct{}
+
+func (NASA) Metadata(ctx context.Context, date time.Time) (*Metadata, error) {
+	q := url.Values{}
+	if !date.IsZero() {
+		q.Set("date", date.Format(time.DateOnly))
+	}
+	var m Metadata
+	if err := nasaGet(ctx, q, &m); err != nil {
+		return nil, err
+	}
+	if err := m.parseDate(); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (NASA) Random(ctx context.Context, count int) ([]*Metadata, error) {
+	q := url.Values{}
+	q.Set("count", strconv.Itoa(count))
+	var ms []*Metadata
+	if err := nasaGet(ctx, q, &ms); err != nil {
+		return nil, err
+	}
+	for _, m := range ms {
+		if err := m.parseDate(); err != nil {
+			return nil, err
+		}
+	}
+	return ms, nil
+}
+
+// parseDate sets ApodDate from the date in the API response.
+func (m *Metadata) parseDate() error {
+	date, err := time.Parse(time.DateOnly, m.Date)
+	if err != nil {
+		return errors.New("NASA APOD API returned an invalid response, it may be down temporarily")
+	}
+	m.ApodDate = date
+	return nil
+}
+
+// nasaGet queries the APOD endpoint and decodes the response into v. Video
+// thumbnails are always requested.
+func nasaGet(ctx context.Context, q url.Values, v any) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*20)
+	defer cancel()
+
+	key := os.Getenv("NASAKEY")
+	if key == "" {
+		key = "DEMO_KEY"
+	}
+	q.Set("api_key", key)
+	q.Set("thumbs", "true")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, nasa.APODEndpoint+"?"+q.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to connect to NASA API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("reading NASA API response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("NASA API returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("decoding NASA API response: %w", err)
+	}
+	return nil
+}
+
+// imageStallTimeout is how long an image download may go without receiving
+// anything before it is given up on. Big images take a while on a slow
+// connection, but they shouldn't stall.
+const imageStallTimeout = 10 * time.Second
+
+func (NASA) Image(ctx context.Context, url string) (io.ReadCloser, int64, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	body := &stallingBody{cancel: cancel}
+	body.timer = time.AfterFunc(imageStallTimeout, body.stall)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		body.Close()
+		return nil, 0, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		body.Close()
+		return nil, 0, fmt.Errorf("downloading image: %w", body.wrap(err))
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		body.Close()
+		return nil, 0, fmt.Errorf("downloading image: %w", &StatusError{Code: resp.StatusCode, Status: resp.Status})
+	}
+	body.ReadCloser = resp.Body
+	return body, resp.ContentLength, nil
+}
+
+// stallingBody is a response body that cancels its request once nothing has
+// been read from it for imageStallTimeout.
+type stallingBody struct {
+	io.ReadCloser
+	timer   *time.Timer
+	cancel  context.CancelFunc
+	stalled atomic.Bool
+}
+
+func (b *stallingBody) stall() {
+	b.stalled.Store(true)
+	b.cancel()
+}
+
+func (b *stallingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		b.timer.Reset(imageStallTimeout)
+	}
+	return n, b.wrap(err)
+}
+
+// wrap makes err a timeout if it is the result of the download stalling.
+func (b *stallingBody) wrap(err error) error {
+	if err != nil && err != io.EOF && b.stalled.Load() {
+		return fmt.Errorf("no data for %s: %w", imageStallTimeout, context.DeadlineExceeded)
+	}
+	return err
+}
+
+func (b *stallingBody) Close() error {
+	b.timer.Stop()
+	defer b.cancel()
+	if b.ReadCloser == nil {
+		return nil
+	}
+	return b.ReadCloser.Close()
+}
+
+// Fixtures serves APODs from a directory, for offline use and tests.
+//
+// Each day is a YYYY-MM-DD.json file in the API's response format. Image URLs
+// are resolved to files in the same directory by their base name, so a
+// response can be saved alongside the image it points to without editing it.
+type Fixtures string
+
+func (f Fixtures) Metadata(_ context.Context, date time.Time) (*Metadata, error) {
+	if date.IsZero() {
+		days, err := f.days()
+		if err != nil {
+			return nil, err
+		}
+		if len(days) == 0 {
+			return nil, fmt.Errorf("no fixtures in %s: %w", string(f), ErrNotFound)
+		}
+		date = days[len(days)-1]
+	}
+
+	byt, err := os.ReadFile(filepath.Join(string(f), Day(date).Format(time.DateOnly)+".json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%s: %w", date.Format(time.DateOnly), ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var img Metadata
+	if err := json.Unmarshal(byt, &img); err != nil {
+		return nil, fmt.Errorf("decoding fixture: %w", err)
+	}
+	if img.ApodDate.IsZero() {
+		img.ApodDate, _ = time.Parse(time.DateOnly, img.Date)
+	}
+	return &img, nil
+}
+
+// Random picks up to count of the days that have a fixture, at random.
+func (f Fixtures) Random(ctx context.Context, count int) ([]*Metadata, error) {
+	days, err := f.days()
+	if err != nil {
+		return nil, err
+	}
+	if len(days) == 0 {
+		return nil, fmt.Errorf("no fixtures in %s: %w", string(f), ErrNotFound)
+	}
+	rand.Shuffle(len(days), func(i, j int) {
+		days[i], days[j] = days[j], days[i]
+	})
+
+	var ms []*Metadata
+	for _, day := range days[:min(count, len(days))] {
+		m, err := f.Metadata(ctx, day)
+		if err != nil {
+			return nil, err
+		}
+		ms = append(ms, m)
+	}
+	return ms, nil
+}
+
+func (f Fixtures) Image(_ context.Context, rawURL string) (io.ReadCloser, int64, error) {
+	name := rawURL
+	if u, err := url.Parse(rawURL); err == nil && u.Path != "" {
+		name = u.Path
+	}
+	file, err := os.Open(filepath.Join(string(f), path.Base(name)))
+	if err != nil {
+		return nil, 0, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, 0, err
+	}
+	return file, info.Size(), nil
+}
+
+// days lists the days that have a fixture, in ascending order.
+func (f Fixtures) days() ([]time.Time, error) {
+	files, err := os.ReadDir(string(f))
+	if err != nil {
+		return nil, err
+	}
+	var days []time.Time
+	for _, file := range files {
+		name, ok := strings.CutSuffix(file.Name(), ".json")
+		if !ok {
+			continue
+		}
+		if day, err := time.Parse(time.DateOnly, name); err == nil {
+			days = append(days, day)
+		}
+	}
+	slices.SortFunc(days, time.Time.Compare)
+	return days, nil
+}
@@ -0,0 +1,189 @@
+package airlockspace
+
+import (
+	"image"
+	"image/color"
+	"strings"
+
+	"github.com/muesli/termenv"
+	"github.com/nfnt/resize"
+)
+
+// brailleDots maps a pixel's position within a 2x4 cell to its dot in the
+// Unicode Braille block.
+var brailleDots = [4][2]rune{
+	{0x01, 0x08},
+	{0x02, 0x10},
+	{0x04, 0x20},
+	{0x40, 0x80},
+}
+
+// renderBraille draws img as Braille patterns, the same style as the art in
+// ascii.go. Each cell holds 2x4 dots that are either on or off, decided by
+// comparing each pixel's brightness against the image's average, or by
+// Floyd–Steinberg error diffusion for RenderBrailleDither. Unless the
+// terminal has no colors, each cell is tinted with the average color of its
+// lit dots.
+func renderBraille(img image.Image, key frameKey) string {
+	profile := key.profile
+	imageWidth, imageHeight := fitImage(img.Bounds().Dx(), img.Bounds().Dy(), key.width*2, key.height*4, key.aspect*2)
+	if imageWidth == 0 || imageHeight == 0 {
+		return ""
+	}
+	scaled := resize.Resize(uint(imageWidth), uint(imageHeight), img, resize.Bilinear)
+
+	lum := normalizedLuminance(scaled)
+	var lit [][]bool
+	if key.mode == RenderBrailleDither {
+		lit = ditherFloydSteinberg(lum, 0.5)
+	} else {
+		lit = threshold(lum, mean(lum))
+	}
+
+	cols, rows := (imageWidth+1)/2, (imageHeight+3)/4
+	chars := make([][]rune, rows)
+	tints := image.NewRGBA(image.Rect(0, 0, cols, rows))
+	for cy := range rows {
+		chars[cy] = make([]rune, cols)
+		for cx := range cols {
+			var (
+				char       rune = 0x2800
+				r, g, b, n uint32
+			)
+			for dy := range 4 {
+				for dx := range 2 {
+					x, y := cx*2+dx, cy*4+dy
+					if x >= imageWidth || y >= imageHeight || !lit[y][x] {
+						continue
+					}
+					char |= brailleDots[dy][dx]
+					pr, pg, pb, _ := scaled.At(x, y).RGBA()
+					r, g, b, n = r+pr>>8, g+pg>>8, b+pb>>8, n+1
+				}
+			}
+			chars[cy][cx] = char
+			if n > 0 {
+				tints.SetRGBA(cx, cy, color.RGBA{uint8(r / n), uint8(g / n), uint8(b / n), 0xff})
+			}
+		}
+	}
+
+	var colors [][]termenv.Color
+	if profile != termenv.Ascii {
+		colors = quantize(tints, profile, key.dither)
+	}
+
+	var s strings.Builder
+	for cy, line := range chars {
+		if cy > 0 {
+			s.WriteString("\n")
+		}
+		var last string
+		for cx, char := range line {
+			// blank cells keep whatever color came before, saving a sequence
+			if colors != nil && char != 0x2800 {
+				seq := sequence(colors[cy][cx], false)
+				if seq != last {
+					s.WriteString(seq)
+					last = seq
+				}
+			}
+			s.WriteRune(char)
+		}
+		if colors != nil {
+			s.WriteString(resetSequence)
+		}
+	}
+	return s.String()
+}
+
+// normalizedLuminance returns the brightness of every pixel of img, stretched
+// so that the darkest pixel is 0 and the brightest is 1. Most APODs are dark,
+// and without stretching they would come out as a handful of dots.
+func normalizedLuminance(img image.Image) [][]float64 {
+	bounds := img.Bounds()
+	lum := make([][]float64, bounds.Dy())
+	lo, hi := 1.0, 0.0
+	for y := range lum {
+		lum[y] = make([]float64, bounds.Dx())
+		for x := range lum[y] {
+			l := luminance(img.At(bounds.Min.X+x, bounds.Min.Y+y))
+			lum[y][x] = l
+			lo, hi = min(lo, l), max(hi, l)
+		}
+	}
+	if hi <= lo {
+		return lum
+	}
+	for y := range lum {
+		for x := range lum[y] {
+			lum[y][x] = (lum[y][x] - lo) / (hi - lo)
+		}
+	}
+	return lum
+}
+
+func mean(lum [][]float64) float64 {
+	var sum float64
+	var n int
+	for _, row := range lum {
+		for _, l := range row {
+			sum += l
+			n++
+		}
+	}
+	if n == 0 {
+		return 0
+	}
+	return sum / float64(n)
+}
+
+// threshold lights up every pixel brighter than t.
+func threshold(lum [][]float64, t float64) [][]bool {
+	lit := make([][]bool, len(lum))
+	for y, row := range lum {
+		lit[y] = make([]bool, len(row))
+		for x, l := range row {
+			lit[y][x] = l > t
+		}
+	}
+	return lit
+}
+
+// ditherFloydSteinberg thresholds lum at t, diffusing each pixel's error
+// onto its unvisited neighbours so that the density of lit pixels follows
+// the original brightness.
+func ditherFloydSteinberg(lum [][]float64, t float64) [][]bool {
+	work := make([][]float64, len(lum))
+	for y := range lum {
+		work[y] = append([]float64(nil), lum[y]...)
+	}
+
+	lit := make([][]bool, len(work))
+	for y, row := range work {
+		lit[y] = make([]bool, len(row))
+		for x, old := range row {
+			var val float64
+			if old > t {
+				val = 1
+				lit[y][x] = true
+			}
+			diffuse(work, x, y, old-val)
+		}
+	}
+	return lit
+}
+
+// diffuse spreads err from (x, y) to its neighbours with the Floyd–Steinberg
+// weights.
+func diffuse(work [][]float64, x, y int, err float64) {
+	add := func(x, y int, weight float64) {
+		if y < len(work) && x >= 0 && x < len(work[y]) {
+			work[y][x] += err * weight
+		}
+	}
+	add(x+1, y, 7.0/16)
+	add(x-1, y+1, 3.0/16)
+	add(x, y+1, 5.0/16)
+	add(x+1, y+1, 1.0/16)
+}
@@ -0,0 +1,200 @@
+package airlockspace
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/kamaln7/airlock.space/apod"
+)
+
+// calendar is the month view of StateCalendar, for picking a day to load
+// without typing a date.
+type calendar struct {
+	cursor time.Time
+	from   State // to return to if nothing is picked
+
+	month  time.Time          // first day of the month cached is for
+	cached map[time.Time]bool // days of month the archive has already
+}
+
+var (
+	keyCalendar = key.NewBinding(
+		key.WithKeys("d"),
+		key.WithHelp("d", "pick date"),
+	)
+	keyCalendarMove = key.NewBinding(
+		key.WithKeys("left", "down", "up", "right", "h", "j", "k", "l"),
+		key.WithHelp("←↓↑→/hjkl", "move"),
+	)
+	keyPrevMonth = key.NewBinding(
+		key.WithKeys("[", "pgup"),
+		key.WithHelp("[", "prev month"),
+	)
+	keyNextMonth = key.NewBinding(
+		key.WithKeys("]", "pgdown"),
+		key.WithHelp("]", "next month"),
+	)
+	keyPrevYear = key.NewBinding(
+		key.WithKeys("{"),
+		key.WithHelp("{", "prev year"),
+	)
+	keyNextYear = key.NewBinding(
+		key.WithKeys("}"),
+		key.WithHelp("}", "next year"),
+	)
+	keyCalendarToday = key.NewBinding(
+		key.WithKeys("t"),
+		key.WithHelp("t", "today"),
+	)
+	keyPick = key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "load"),
+	)
+	keyCalendarClose = key.NewBinding(
+		key.WithKeys("esc", "d"),
+		key.WithHelp("esc", "back"),
+	)
+)
+
+// calendarMoves maps keyCalendarMove's keys to the days they move by.
+var calendarMoves = map[string]int{
+	"left": -1, "h": -1,
+	"right": 1, "l": 1,
+	"up": -7, "k": -7,
+	"down": 7, "j": 7,
+}
+
+// openCalendar switches to StateCalendar with the cursor on the day shown.
+func (m *Model) openCalendar() {
+	m.calendar = calendar{from: m.State}
+	m.State = StateCalendar
+	m.moveCalendar(m.currentDate())
+}
+
+// closeCalendar goes back to what was shown before the calendar, or to
+// whatever the APOD calls for if it changed in the meantime.
+func (m *Model) closeCalendar() {
+	switch {
+	case m.apod != nil && (m.calendar.from == StateLink || m.calendar.from == StateFullscreen && m.image != nil):
+		m.State = m.calendar.from
+	default:
+		m.State = m.mainState()
+	}
+}
+
+// calendarKey handles key presses in StateCalendar.
+func (m *Model) calendarKey(msg tea.KeyMsg) tea.Cmd {
+	cursor := m.calendar.cursor
+	switch {
+	case key.Matches(msg, keyCalendarMove):
+		m.moveCalendar(cursor.AddDate(0, 0, calendarMoves[msg.String()]))
+	case key.Matches(msg, keyPrevMonth):
+		m.moveCalendar(addMonths(cursor, -1))
+	case key.Matches(msg, keyNextMonth):
+		m.moveCalendar(addMonths(cursor, 1))
+	case key.Matches(msg, keyPrevYear):
+		m.moveCalendar(addMonths(cursor, -12))
+	case key.Matches(msg, keyNextYear):
+		m.moveCalendar(addMonths(cursor, 12))
+	case key.Matches(msg, keyCalendarToday):
+		m.moveCalendar(apod.Day(time.Now()))
+	case key.Matches(msg, keyPick):
+		if cursor.Equal(m.currentDate()) && m.apod != nil {
+			m.closeCalendar()
+			return nil
+		}
+		return m.goTo(cursor)
+	case key.Matches(msg, keyCalendarClose):
+		m.closeCalendar()
+	}
+	return nil
+}
+
+// moveCalendar puts the cursor on date, or the closest day the archive has,
+// and looks up which days of its month are cached when the month changes.
+func (m *Model) moveCalendar(date time.Time) {
+	today := apod.Day(time.Now())
+	switch {
+	case date.Before(apod.ArchiveStart):
+		date = apod.ArchiveStart
+	case date.After(today):
+		date = today
+	}
+	m.calendar.cursor = date
+
+	month := date.AddDate(0, 0, 1-date.Day())
+	if month.Equal(m.calendar.month) {
+		return
+	}
+	m.calendar.month = month
+	m.calendar.cached = map[time.Time]bool{}
+	for day := month; day.Month() == month.Month() && !day.After(today); day = day.AddDate(0, 0, 1) {
+		if m.archive().Cached(day) {
+			m.calendar.cached[day] = true
+		}
+	}
+}
+
+// addMonths moves t by n months, staying within the month it lands in
+// rather than spilling over like time.AddDate does from the 31st.
+func addMonths(t time.Time, n int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(n), 1, 0, 0, 0, 0, time.UTC)
+	last := first.AddDate(0, 1, -1).Day()
+	return first.AddDate(0, 0, min(t.Day(), last)-1)
+}
+
+// viewCalendar is the month of the cursor, with the days that are cached
+// highlighted and those outside the archive muted.
+func (m *Model) viewCalendar() string {
+	c := &m.calendar
+	today := apod.Day(time.Now())
+	cached := m.Style.Foreground(colorCosmic).Bold(true)
+
+	var grid strings.Builder
+	grid.WriteString(m.txtMuted().Render("Su Mo Tu We Th Fr Sa"))
+	grid.WriteString("\n" + strings.Repeat("   ", int(c.month.Weekday())))
+	for day := c.month; day.Month() == c.month.Month(); day = day.AddDate(0, 0, 1) {
+		if day.Weekday() == time.Sunday && day.Day() > 1 {
+			grid.WriteString("\n")
+		} else if day.Day() > 1 {
+			grid.WriteString(" ")
+		}
+
+		style := m.Style
+		switch {
+		case day.Before(apod.ArchiveStart) || day.After(today):
+			style = m.txtSuperMuted()
+		case c.cached[day]:
+			style = cached
+		}
+		if day.Equal(m.currentDate()) {
+			style = style.Underline(true)
+		}
+		if day.Equal(c.cursor) {
+			style = style.Reverse(true)
+		}
+		grid.WriteString(style.Render(fmt.Sprintf("%2d", day.Day())))
+	}
+
+	title := m.txtYellow().Bold(true).Render(c.month.Format("January 2006"))
+	box := m.Style.
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorNebula).
+		Padding(0, 1).
+		Render(lipgloss.JoinVertical(lipgloss.Center, title, "", m.Style.Width(20).Render(grid.String())))
+	legend := cached.Render("■") + m.txtMuted().Render(" fetched already") +
+		m.divDot().String() + m.txtMuted().Render(c.cursor.Format("Monday, 2 January 2006"))
+
+	return lipgloss.Place(m.Width, m.Height, lipgloss.Center, lipgloss.Center,
+		lipgloss.JoinVertical(lipgloss.Center,
+			box,
+			legend,
+			m.viewHelp(keyCalendarMove, keyPrevMonth, keyNextMonth, keyPrevYear, keyNextYear),
+			m.viewHelp(keyCalendarToday, keyPick, keyCalendarClose, keyQuit),
+		),
+	)
+}
@@ -0,0 +1,32 @@
+package airlockspace
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAddMonths(t *testing.T) {
+	tests := []struct {
+		from string
+		n    int
+		want string
+	}{
+		{"2024-01-15", 1, "2024-02-15"},
+		{"2024-01-31", 1, "2024-02-29"}, // not March 2nd
+		{"2023-01-31", 1, "2023-02-28"},
+		{"2024-03-31", -1, "2024-02-29"},
+		{"2024-12-31", 1, "2025-01-31"},
+		{"2024-01-31", -1, "2023-12-31"},
+		{"2024-02-29", 12, "2025-02-28"},
+		{"2024-02-29", -12, "2023-02-28"},
+	}
+	for _, tt := range tests {
+		from, err := time.Parse(time.DateOnly, tt.from)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := addMonths(from, tt.n).Format(time.DateOnly); got != tt.want {
+			t.Errorf("addMonths(%s, %d) = %s, want %s", tt.from, tt.n, got, tt.want)
+		}
+	}
+}
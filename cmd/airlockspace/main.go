@@ -4,12 +4,16 @@ package main
 // and continually print up to date terminal information.
 
 import (
+	"flag"
 	"fmt"
 	"os"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/term"
 	airlockspace "github.com/kamaln7/airlock.space"
+	"github.com/kamaln7/airlock.space/apod"
 )
 
 const (
@@ -17,13 +21,75 @@ const (
 	port = "23234"
 )
 
+var (
+	fixtures   = flag.String("fixtures", "", "serve APODs from a directory of fixtures instead of NASA's API")
+	render     = flag.String("render", "", "how to draw the image: ascii, half-block, braille, braille-dither, kitty, iterm2 or sixel (default depends on the terminal)")
+	dither     = flag.String("dither", "auto", "how to dither colors the terminal can't show: auto, none, bayer or floyd-steinberg")
+	cellAspect = flag.Float64("cell-aspect", 0, "width / height of a terminal cell (default asks the terminal, or 0.5)")
+	slideshow  = flag.String("slideshow", "", "start in a slideshow of recent or random APODs")
+	interval   = flag.Duration("interval", 30*time.Second, "how long the slideshow shows each APOD for")
+)
+
 func main() {
+	flag.Parse()
+
+	renderer := lipgloss.NewRenderer(os.Stdout)
+	caps := queryCapabilities()
 	m := &airlockspace.Model{
-		Style: lipgloss.NewRenderer(os.Stdout).NewStyle(),
+		Style:      renderer.NewStyle(),
+		Profile:    renderer.ColorProfile(),
+		Graphics:   airlockspace.DetectGraphics(os.Getenv("TERM"), os.Environ(), caps),
+		Hyperlinks: airlockspace.DetectHyperlinks(os.Getenv("TERM"), os.Environ()),
+		CellAspect: *cellAspect,
+		Term:       os.Getenv("TERM"),
+	}
+	if cols, rows, err := term.GetSize(os.Stdout.Fd()); err == nil {
+		m.CellSize = caps.CellPixels(cols, rows)
+	}
+	if *render == "" {
+		m.RenderMode = airlockspace.DefaultRenderMode(m.Profile, m.Graphics)
+	} else if mode, ok := airlockspace.ParseRenderMode(*render); ok {
+		m.RenderMode = mode
+	} else {
+		fmt.Fprintf(os.Stderr, "unknown render mode %q\n", *render)
+		os.Exit(2)
+	}
+	if d, ok := airlockspace.ParseDither(*dither); ok {
+		m.Dither = d
+	} else {
+		fmt.Fprintf(os.Stderr, "unknown dither %q\n", *dither)
+		os.Exit(2)
 	}
-	p := tea.NewProgram(m, tea.WithAltScreen())
+	if *slideshow != "" {
+		if order, ok := airlockspace.ParseSlideshowOrder(*slideshow); ok {
+			m.Slideshow, m.SlideshowOrder = true, order
+		} else {
+			fmt.Fprintf(os.Stderr, "unknown slideshow order %q\n", *slideshow)
+			os.Exit(2)
+		}
+	}
+	m.SlideshowInterval = *interval
+	if *fixtures != "" {
+		m.Archive = apod.New(apod.Fixtures(*fixtures))
+	}
+	p := tea.NewProgram(m, tea.WithAltScreen(), tea.WithMouseCellMotion())
 	if _, err := p.Run(); err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
 }
+
+// queryCapabilities asks the terminal what it supports, if there is one.
+func queryCapabilities() airlockspace.Capabilities {
+	var caps airlockspace.Capabilities
+	if !term.IsTerminal(os.Stdin.Fd()) {
+		return caps
+	}
+	state, err := term.MakeRaw(os.Stdin.Fd())
+	if err != nil {
+		return caps
+	}
+	defer term.Restore(os.Stdin.Fd(), state)
+	caps, _ = airlockspace.QueryCapabilities(os.Stdin, os.Stdout, time.Second)
+	return caps
+}
@@ -6,6 +6,7 @@ package main
 import (
 	"context"
 	"errors"
+	"image"
 	"net"
 	"os"
 	"os/signal"
@@ -22,15 +23,38 @@ import (
 	"github.com/charmbracelet/wish/bubbletea"
 	"github.com/charmbracelet/wish/logging"
 	airlockspace "github.com/kamaln7/airlock.space"
+	"github.com/kamaln7/airlock.space/apod"
 	"github.com/muesli/termenv"
 )
 
 var (
 	host = GetEnv("SSH_HOST", "localhost")
 	port = GetEnv("SSH_PORT", "23234")
+
+	cacheDir    = GetEnv("APOD_CACHE_DIR", ".airlocksshd/apod")
+	cacheMaxMB  = GetEnv("APOD_CACHE_MAX_MB", "512")
+	fixturesDir = GetEnv("APOD_FIXTURES_DIR", "")
+
+	archive = apod.Default
 )
 
 func main() {
+	if fixturesDir != "" {
+		archive = apod.New(apod.Fixtures(fixturesDir))
+		log.Info("serving APOD fixtures", "dir", fixturesDir)
+	} else {
+		maxMB, err := strconv.ParseInt(cacheMaxMB, 10, 64)
+		if err != nil {
+			log.Fatal("invalid APOD_CACHE_MAX_MB", "error", err)
+		}
+		cache, err := apod.NewDiskCache(cacheDir, maxMB<<20)
+		if err != nil {
+			log.Fatal("could not create APOD cache", "error", err)
+		}
+		archive.UseDiskCache(cache)
+		log.Info("using APOD cache", "dir", cacheDir, "max_mb", maxMB)
+	}
+
 	s, err := wish.NewServer(
 		wish.WithAddress(net.JoinHostPort(host, port)),
 		wish.WithHostKeyPath(GetEnv("SSH_HOST_KEY", ".airlocksshd/id_ed25519")),
@@ -96,12 +120,34 @@ func teaHandler(s ssh.Session) (tea.Model, []tea.ProgramOption) {
 	renderer := bubbletea.MakeRenderer(s)
 	var colorTerm string
 	var isIterm2 bool
+	var renderModeName string
+	var dither airlockspace.Dither
+	var cellAspect float64
 	for _, env := range s.Environ() {
 		if strings.HasPrefix(env, "COLORTERM=") {
 			colorTerm = strings.TrimPrefix(env, "COLORTERM=")
 			continue
 		}
 
+		if value, ok := strings.CutPrefix(env, "AIRLOCK_RENDER="); ok {
+			renderModeName = value
+			continue
+		}
+
+		if value, ok := strings.CutPrefix(env, "AIRLOCK_DITHER="); ok {
+			if d, ok := airlockspace.ParseDither(value); ok {
+				dither = d
+			}
+			continue
+		}
+
+		if value, ok := strings.CutPrefix(env, "AIRLOCK_CELL_ASPECT="); ok {
+			if aspect, err := strconv.ParseFloat(value, 64); err == nil && aspect > 0 {
+				cellAspect = aspect
+			}
+			continue
+		}
+
 		if strings.EqualFold(env, "TERM_PROGRAM=iTerm2") || strings.EqualFold(env, "LC_TERMINAL=iTerm2") {
 			isIterm2 = true
 			continue
@@ -109,12 +155,34 @@ func teaHandler(s ssh.Session) (tea.Model, []tea.ProgramOption) {
 	}
 	renderer.SetColorProfile(getSSHTermInfo(pty.Term, colorTerm, isIterm2))
 
+	// The terminal isn't asked what it can draw: a read from the session
+	// can't be canceled, so a terminal that doesn't answer would hold the
+	// session up until a key is pressed, and that key would be lost. Some
+	// clients send the window's pixel size along with the pty request.
+	caps := airlockspace.Capabilities{
+		TextArea: image.Pt(pty.Window.WidthPixels, pty.Window.HeightPixels),
+	}
+	graphics := airlockspace.DetectGraphics(pty.Term, s.Environ(), caps)
+	renderMode := airlockspace.DefaultRenderMode(renderer.ColorProfile(), graphics)
+	if mode, ok := airlockspace.ParseRenderMode(renderModeName); ok {
+		renderMode = mode
+	}
+
 	m := &airlockspace.Model{
-		Width:  pty.Window.Width,
-		Height: pty.Window.Height,
-		Style:  renderer.NewStyle(),
+		Width:      pty.Window.Width,
+		Height:     pty.Window.Height,
+		Style:      renderer.NewStyle(),
+		Profile:    renderer.ColorProfile(),
+		RenderMode: renderMode,
+		Graphics:   graphics,
+		Hyperlinks: airlockspace.DetectHyperlinks(pty.Term, s.Environ()),
+		Dither:     dither,
+		CellAspect: cellAspect,
+		CellSize:   caps.CellPixels(pty.Window.Width, pty.Window.Height),
+		Term:       pty.Term,
+		Archive:    archive,
 	}
-	return m, []tea.ProgramOption{tea.WithAltScreen()}
+	return m, []tea.ProgramOption{tea.WithAltScreen(), tea.WithMouseCellMotion()}
 }
 
 func GetEnv(name, fallback string) string {
@@ -0,0 +1,70 @@
+package airlockspace
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aymanbagabas/go-osc52/v2"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// copiedFor is how long "copied!" stays up after a copy.
+const copiedFor = 2 * time.Second
+
+var (
+	keyCopy = key.NewBinding(
+		key.WithKeys("c"),
+		key.WithHelp("c", "copy link"),
+	)
+	keyCopyDetails = key.NewBinding(
+		key.WithKeys("C"),
+		key.WithHelp("C", "copy with details"),
+	)
+)
+
+// copiedExpiredMsg takes down the "copied!" indicator.
+type copiedExpiredMsg struct{}
+
+// apodURL is the APOD's page on NASA's site.
+func (m *Model) apodURL() string {
+	return fmt.Sprintf("https://apod.nasa.gov/apod/ap%s.html", m.currentDate().Format("060102"))
+}
+
+// copyDetails is the title, date and credit of the APOD followed by its link.
+func (m *Model) copyDetails() string {
+	var s strings.Builder
+	if m.apod != nil && m.apod.Title != "" {
+		s.WriteString(m.apod.Title + "\n")
+	}
+	s.WriteString(m.currentDate().Format(time.DateOnly) + "\n")
+	if m.apod != nil {
+		if credit := strings.Join(strings.Fields(m.apod.Copyright), " "); credit != "" {
+			s.WriteString("Credit: " + credit + "\n")
+		}
+	}
+	s.WriteString(m.apodURL())
+	return s.String()
+}
+
+// copy puts text on the terminal's clipboard with OSC 52, which works over
+// SSH as long as the terminal allows it. Inside tmux or screen the sequence
+// has to be passed through to the terminal outside. The sequence goes out
+// with the view, since writing to the terminal behind Bubble Tea's back
+// could land in the middle of a frame; the renderer only sends lines that
+// changed, so it is sent once.
+func (m *Model) copy(text string) tea.Cmd {
+	seq := osc52.New(text)
+	switch {
+	case strings.HasPrefix(m.Term, "tmux"):
+		seq = seq.Tmux()
+	case strings.HasPrefix(m.Term, "screen"):
+		seq = seq.Screen()
+	}
+	m.clipboard = seq.String()
+	m.copiedRecently = true
+	return tea.Tick(copiedFor, func(time.Time) tea.Msg {
+		return copiedExpiredMsg{}
+	})
+}
@@ -0,0 +1,183 @@
+package airlockspace
+
+import (
+	"image"
+	"image/color"
+	"strings"
+
+	"github.com/muesli/termenv"
+)
+
+// Dither picks how colors are spread over neighbouring cells when the
+// terminal has too few of them to show the image as is.
+type Dither int
+
+const (
+	DitherAuto           Dither = iota // whatever DefaultDither picks
+	DitherNone                         // nearest color, bands and all
+	DitherBayer                        // ordered, with a 4x4 Bayer matrix
+	DitherFloydSteinberg               // error diffusion
+)
+
+var dithers = []Dither{DitherAuto, DitherNone, DitherBayer, DitherFloydSteinberg}
+
+func (d Dither) String() string {
+	switch d {
+	case DitherAuto:
+		return "auto"
+	case DitherNone:
+		return "none"
+	case DitherBayer:
+		return "bayer"
+	case DitherFloydSteinberg:
+		return "floyd-steinberg"
+	}
+	return "unknown"
+}
+
+// ParseDither parses a dither as named by String, e.g. from an environment
+// variable.
+func ParseDither(s string) (Dither, bool) {
+	for _, d := range dithers {
+		if strings.EqualFold(s, d.String()) {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+// DefaultDither is the dither that suits profile best. With 16 colors error
+// diffusion is the only thing that keeps gradients recognizable; with 256 a
+// Bayer pattern is enough to break up the bands and doesn't crawl when the
+// image is resized.
+func DefaultDither(profile termenv.Profile) Dither {
+	switch profile {
+	case termenv.ANSI:
+		return DitherFloydSteinberg
+	case termenv.ANSI256:
+		return DitherBayer
+	}
+	return DitherNone
+}
+
+// resolve replaces DitherAuto with what it stands for.
+func (d Dither) resolve(profile termenv.Profile) Dither {
+	if d == DitherAuto {
+		return DefaultDither(profile)
+	}
+	return d
+}
+
+// bayer4 is the 4x4 ordered dither matrix, thresholds 0..15.
+var bayer4 = [4][4]float64{
+	{0, 8, 2, 10},
+	{12, 4, 14, 6},
+	{3, 11, 1, 9},
+	{15, 7, 13, 5},
+}
+
+// quantize maps every pixel of img to a color profile can show, dithering
+// with d on the way. Pixels are indexed [y][x] from the image's origin.
+func quantize(img image.Image, profile termenv.Profile, d Dither) [][]termenv.Color {
+	bounds := img.Bounds()
+	out := make([][]termenv.Color, bounds.Dy())
+	for y := range out {
+		out[y] = make([]termenv.Color, bounds.Dx())
+	}
+
+	palette, colors, spread := profilePalette(profile)
+	d = d.resolve(profile)
+	if palette == nil || d == DitherNone {
+		for y := range out {
+			for x := range out[y] {
+				out[y][x] = profile.FromColor(img.At(bounds.Min.X+x, bounds.Min.Y+y))
+			}
+		}
+		return out
+	}
+
+	// channels as floats, so that error diffusion can push them out of range
+	var planes [3][][]float64
+	for c := range planes {
+		planes[c] = make([][]float64, bounds.Dy())
+		for y := range planes[c] {
+			planes[c][y] = make([]float64, bounds.Dx())
+		}
+	}
+	for y := range out {
+		for x := range out[y] {
+			r, g, b, _ := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			planes[0][y][x], planes[1][y][x], planes[2][y][x] = float64(r>>8), float64(g>>8), float64(b>>8)
+		}
+	}
+
+	for y := range out {
+		for x := range out[y] {
+			var want [3]float64
+			for c := range planes {
+				want[c] = planes[c][y][x]
+			}
+			if d == DitherBayer {
+				offset := ((bayer4[y%4][x%4]+0.5)/16 - 0.5) * spread
+				for c := range want {
+					want[c] += offset
+				}
+			}
+
+			i := nearestColor(palette, want)
+			out[y][x] = colors[i]
+
+			if d == DitherFloydSteinberg {
+				got := [3]float64{float64(palette[i].R), float64(palette[i].G), float64(palette[i].B)}
+				for c := range planes {
+					diffuse(planes[c], x, y, want[c]-got[c])
+				}
+			}
+		}
+	}
+	return out
+}
+
+// profilePalette returns the colors profile can show, in RGB and as termenv
+// colors, along with the rough distance between neighbouring colors that
+// ordered dithering should bridge. The palette is nil if there is no point in
+// dithering for profile.
+func profilePalette(profile termenv.Profile) ([]color.RGBA, []termenv.Color, float64) {
+	var colors []termenv.Color
+	var spread float64
+	switch profile {
+	case termenv.ANSI:
+		for i := range 16 {
+			colors = append(colors, termenv.ANSIColor(i))
+		}
+		spread = 255.0 / 3
+	case termenv.ANSI256:
+		// 0..15 are the same as ANSI, and configurable by the user
+		for i := 16; i < 256; i++ {
+			colors = append(colors, termenv.ANSI256Color(i))
+		}
+		spread = 255.0 / 5
+	default:
+		return nil, nil, 0
+	}
+
+	palette := make([]color.RGBA, len(colors))
+	for i, c := range colors {
+		r, g, b, _ := termenv.ConvertToRGB(c).RGBA()
+		palette[i] = color.RGBA{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8), 0xff}
+	}
+	return palette, colors, spread
+}
+
+// nearestColor returns the index of the palette color closest to c.
+func nearestColor(palette []color.RGBA, c [3]float64) int {
+	best, bestDist := 0, -1.0
+	for i, p := range palette {
+		dr, dg, db := c[0]-float64(p.R), c[1]-float64(p.G), c[2]-float64(p.B)
+		dist := dr*dr + dg*dg + db*db
+		if bestDist < 0 || dist < bestDist {
+			best, bestDist = i, dist
+		}
+	}
+	return best
+}
@@ -0,0 +1,93 @@
+package airlockspace
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/bubbles/progress"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kamaln7/airlock.space/apod"
+)
+
+// progressInterval is how often at most the view catches up with a
+// download.
+const progressInterval = 100 * time.Millisecond
+
+// download is what the view knows about the download it is waiting on, as
+// of the last progressMsg.
+type download struct {
+	progress    *apod.Progress  // nil if there is none
+	done        <-chan struct{} // closed once the load behind it is over
+	started     bool
+	read, total int64 // total is -1 if unknown
+	eta         time.Duration
+	hasETA      bool
+}
+
+// progressMsg is how far a download got.
+type progressMsg download
+
+// watchDownload makes p the download the view follows, until done is
+// closed.
+func (m *Model) watchDownload(p *apod.Progress, done <-chan struct{}) tea.Cmd {
+	m.download = download{progress: p, done: done}
+	return waitProgress(m.download)
+}
+
+// waitProgress waits for d to move along and reports how far it got. Once
+// its load is over it reports nothing, and the model stops asking.
+func waitProgress(d download) tea.Cmd {
+	changed := d.progress.Changed()
+	return func() tea.Msg {
+		select {
+		case <-d.done:
+			return nil
+		case <-time.After(progressInterval):
+		}
+		select {
+		case <-d.done:
+			return nil
+		case <-changed:
+		}
+		d.read, d.total = d.progress.Bytes()
+		d.started = d.progress.Started()
+		d.eta, d.hasETA = d.progress.ETA()
+		return progressMsg(d)
+	}
+}
+
+// viewDownload shows how far the current download got in at most width
+// cells: a progress bar with the time left if the size is known, the bytes
+// read so far otherwise.
+func (m *Model) viewDownload(width int) string {
+	d := m.download
+	if d.progress == nil || !d.started {
+		return ""
+	}
+	read, total := d.read, d.total
+	if total <= 0 {
+		return m.txtMuted().Render(formatBytes(read))
+	}
+
+	bar := progress.New(
+		progress.WithDefaultGradient(),
+		progress.WithColorProfile(m.Profile),
+		progress.WithWidth(min(40, width)),
+	)
+	status := fmt.Sprintf("%s of %s", formatBytes(read), formatBytes(total))
+	if d.hasETA {
+		status += fmt.Sprintf(" • about %s left", d.eta.Round(time.Second))
+	}
+	return bar.ViewAs(float64(read)/float64(total)) + "\n" + m.txtMuted().Render(status)
+}
+
+// formatBytes formats n for people.
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%d kB", n>>10)
+	}
+	return fmt.Sprintf("%d B", n)
+}
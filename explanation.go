@@ -0,0 +1,87 @@
+package airlockspace
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/reflow/wordwrap"
+)
+
+// explanationWidth is the widest the explanation is wrapped at.
+const explanationWidth = 60
+
+// explanationKeys scroll the explanation. The viewport's defaults would
+// shadow the app's own keys, e.g. "f" for fullscreen and "l" for link.
+var explanationKeys = viewport.KeyMap{
+	PageDown: key.NewBinding(
+		key.WithKeys("pgdown", " "),
+		key.WithHelp("pgdn", "page down"),
+	),
+	PageUp: key.NewBinding(
+		key.WithKeys("pgup"),
+		key.WithHelp("pgup", "page up"),
+	),
+	HalfPageUp: key.NewBinding(
+		key.WithKeys("ctrl+u"),
+		key.WithHelp("ctrl+u", "½ page up"),
+	),
+	HalfPageDown: key.NewBinding(
+		key.WithKeys("ctrl+d"),
+		key.WithHelp("ctrl+d", "½ page down"),
+	),
+	Up: key.NewBinding(
+		key.WithKeys("up", "k"),
+		key.WithHelp("↑/k", "up"),
+	),
+	Down: key.NewBinding(
+		key.WithKeys("down", "j"),
+		key.WithHelp("↓/j", "down"),
+	),
+}
+
+// newExplanation returns the viewport the explanation is scrolled in.
+func newExplanation() viewport.Model {
+	vp := viewport.New(0, 0)
+	vp.KeyMap = explanationKeys
+	return vp
+}
+
+// explanationShown reports whether the view is the explanation.
+func (m *Model) explanationShown() bool {
+	return m.State == StateAPOD && !m.imgOrExplanation && m.apod != nil
+}
+
+// explanationLayout renders what surrounds the explanation and sizes the
+// viewport to the height left between them. The scroll position is only
+// shown if there is anything to scroll.
+func (m *Model) explanationLayout() (header, footer string) {
+	width := min(explanationWidth, m.Width-2) // -2 for the margin
+	header = strings.TrimSuffix(m.apodView(width, false).View(), "\n")
+	help := m.viewHelpWrapped(width, m.mainKeys()...)
+
+	content := wordwrap.String(m.apod.Explanation, width)
+	height := max(1, m.Height-2-lipgloss.Height(header)-lipgloss.Height(help)) // -2 for the margins
+	lines := lipgloss.Height(content)
+	if lines > height {
+		height = max(1, height-1) // for the position
+	}
+	m.explanation.Width = width
+	m.explanation.Height = min(lines, height)
+	m.explanation.SetContent(content)
+
+	footer = help
+	if lines > height {
+		position := fmt.Sprintf("↑↓ scroll • %d%%", int(m.explanation.ScrollPercent()*100))
+		footer = m.txtMuted().Render(position) + "\n" + help
+	}
+	return header, footer
+}
+
+// viewExplanation is the APOD with its explanation in a viewport.
+func (m *Model) viewExplanation() string {
+	header, footer := m.explanationLayout()
+	return lipgloss.JoinVertical(lipgloss.Left, header, m.explanation.View(), footer)
+}
@@ -0,0 +1,289 @@
+package airlockspace
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"hash/fnv"
+	"image"
+	"image/jpeg"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/charmbracelet/x/ansi/iterm2"
+	"github.com/charmbracelet/x/ansi/kitty"
+	"github.com/charmbracelet/x/input"
+	"github.com/nfnt/resize"
+)
+
+// inlineCellPixels is roughly how many pixels a terminal cell covers, used to
+// decide how big an image to send to terminals that draw it themselves.
+var inlineCellPixels = image.Pt(10, 20)
+
+// graphicsModes are the modes that need the terminal to support an image
+// protocol, see DetectGraphics.
+var graphicsModes = []RenderMode{RenderKitty, RenderITerm2, RenderSixel}
+
+// Capabilities is what a terminal reports about itself when asked, see
+// QueryCapabilities.
+type Capabilities struct {
+	Sixel    bool
+	CellSize image.Point // in pixels, zero if not reported
+	TextArea image.Point // in pixels, zero if not reported
+}
+
+// CellPixels is the pixel size of a cell in a terminal of cols x rows cells:
+// the reported cell size if there is one, otherwise the text area divided by
+// the number of cells. It is zero if the terminal reported neither.
+func (c Capabilities) CellPixels(cols, rows int) image.Point {
+	switch {
+	case c.CellSize.X > 0 && c.CellSize.Y > 0:
+		return c.CellSize
+	case c.TextArea.X > 0 && c.TextArea.Y > 0 && cols > 0 && rows > 0:
+		return image.Pt(c.TextArea.X/cols, c.TextArea.Y/rows)
+	}
+	return image.Point{}
+}
+
+// QueryCapabilities asks the terminal at the other end of in and out what it
+// supports and how big its cells are, waiting at most timeout for an answer.
+// The terminal has to be in raw mode, or the answer would be echoed and line
+// buffered, and in has to be a file whose reads can be canceled, such as
+// os.Stdin, or a terminal that doesn't answer would block it until the next
+// key press. Every terminal answers the device attributes request, so it goes
+// last and its answer marks the end of the others.
+func QueryCapabilities(in io.Reader, out io.Writer, timeout time.Duration) (Capabilities, error) {
+	var caps Capabilities
+	rd, err := input.NewReader(in, "", 0)
+	if err != nil {
+		return caps, err
+	}
+	defer rd.Close()
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-done:
+		case <-time.After(timeout):
+			rd.Cancel()
+		}
+	}()
+
+	query := ansi.WindowOp(ansi.RequestCellSizeWinOp) +
+		ansi.WindowOp(ansi.RequestWindowSizeWinOp) +
+		ansi.RequestPrimaryDeviceAttributes
+	if _, err := io.WriteString(out, query); err != nil {
+		return caps, err
+	}
+	for {
+		events, err := rd.ReadEvents()
+		if err != nil {
+			return caps, err
+		}
+		for _, event := range events {
+			switch event := event.(type) {
+			case input.WindowOpEvent:
+				// sizes are reported as height, width
+				if len(event.Args) < 2 {
+					break
+				}
+				size := image.Pt(event.Args[1], event.Args[0])
+				switch event.Op {
+				case 6: // reply to RequestCellSizeWinOp
+					caps.CellSize = size
+				case 4: // reply to RequestWindowSizeWinOp
+					caps.TextArea = size
+				}
+			case input.PrimaryDeviceAttributesEvent:
+				// attribute 4 is sixel graphics
+				caps.Sixel = slices.Contains(event, 4)
+				return caps, nil
+			}
+		}
+	}
+}
+
+// DetectGraphics returns the image protocols the terminal supports, best
+// first, judging by its TERM, environment variables and what it answered to
+// QueryCapabilities. Over SSH only a few variables make it across
+// (LC_TERMINAL does, which is why iTerm2 sets it).
+func DetectGraphics(term string, environ []string, caps Capabilities) []RenderMode {
+	var modes []RenderMode
+	add := func(mode RenderMode) {
+		if !slices.Contains(modes, mode) {
+			modes = append(modes, mode)
+		}
+	}
+
+	switch strings.ToLower(term) {
+	case "xterm-kitty", "xterm-ghostty":
+		add(RenderKitty)
+	case "wezterm":
+		add(RenderITerm2)
+	case "foot", "foot-extra", "mlterm", "contour":
+		add(RenderSixel)
+	}
+	for _, env := range environ {
+		name, value, _ := strings.Cut(env, "=")
+		switch {
+		case name == "KITTY_WINDOW_ID":
+			add(RenderKitty)
+		case (name == "TERM_PROGRAM" || name == "LC_TERMINAL") &&
+			(strings.EqualFold(value, "iTerm2") || strings.EqualFold(value, "WezTerm")):
+			add(RenderITerm2)
+		}
+	}
+	if caps.Sixel {
+		add(RenderSixel)
+	}
+	return modes
+}
+
+// inline reports whether the terminal draws the image itself, as opposed to
+// it being made of characters.
+func (r RenderMode) inline() bool {
+	return slices.Contains(graphicsModes, r)
+}
+
+// inlineImage scales img down to about the pixel size of cols x rows cells
+// so that no more is sent to the terminal than it can show.
+func inlineImage(img image.Image, key frameKey, cols, rows int) image.Image {
+	cell := key.cellPixels(inlineCellPixels)
+	width, height := fitImage(img.Bounds().Dx(), img.Bounds().Dy(), cols*cell.X, rows*cell.Y, 1)
+	if width >= img.Bounds().Dx() {
+		return img
+	}
+	return resize.Resize(uint(width), uint(height), img, resize.Bilinear)
+}
+
+// renderKitty transmits img with the kitty graphics protocol and places it
+// with Unicode placeholders: every cell of the image is a character that the
+// terminal replaces with its part of the image. As far as the layout is
+// concerned the frame is ordinary text, so it can be positioned with lipgloss
+// and disappears as soon as something else is drawn over it.
+func renderKitty(img image.Image, key frameKey) (string, error) {
+	cols, rows := fitImage(img.Bounds().Dx(), img.Bounds().Dy(), key.width, key.height, key.aspect)
+	cols, rows = min(cols, kittyMaxCells), min(rows, kittyMaxCells)
+	if cols == 0 || rows == 0 {
+		return "", nil
+	}
+
+	id := kittyImageID(key)
+	var s strings.Builder
+	err := ansi.WriteKittyGraphics(&s, inlineImage(img, key, cols, rows), &kitty.Options{
+		Action:           kitty.TransmitAndPut,
+		Quite:            2, // replies would arrive as key presses
+		ID:               id,
+		Format:           kitty.PNG,
+		Transmission:     kitty.Direct,
+		Chunk:            true,
+		VirtualPlacement: true,
+		Columns:          cols,
+		Rows:             rows,
+	})
+	if err != nil {
+		return "", fmt.Errorf("encoding image for kitty: %w", err)
+	}
+
+	// the placeholders' foreground color is the image ID
+	color := fmt.Sprintf("\x1b[38;2;%d;%d;%dm", id>>16&0xff, id>>8&0xff, id&0xff)
+	for row := range rows {
+		if row > 0 {
+			s.WriteString("\n")
+		}
+		s.WriteString(color)
+		for col := range cols {
+			s.WriteRune(kitty.Placeholder)
+			s.WriteRune(kitty.Diacritic(row))
+			s.WriteRune(kitty.Diacritic(col))
+		}
+		s.WriteString(resetSequence)
+	}
+	return s.String(), nil
+}
+
+// kittyMaxCells is how many rows or columns a placeholder can address, one
+// for each combining character kitty assigns a number to.
+const kittyMaxCells = 297
+
+// kittyImageID derives an ID from the frame so that sessions showing the same
+// frame agree on it, and different frames don't overwrite each other. It fits
+// in 24 bits to be expressible as a placeholder color.
+func kittyImageID(key frameKey) int {
+	h := fnv.New32a()
+	fmt.Fprintf(h, "%s %dx%d %v", key.date, key.width, key.height, key.crop)
+	id := int(h.Sum32() & 0xffffff)
+	if id == 0 {
+		id = 1
+	}
+	return id
+}
+
+// renderITerm2 draws img with iTerm2's inline image protocol, which WezTerm
+// also speaks.
+func renderITerm2(img image.Image, key frameKey) (string, error) {
+	cols, rows := fitImage(img.Bounds().Dx(), img.Bounds().Dy(), key.width, key.height-1, key.aspect)
+	if cols == 0 || rows == 0 {
+		return "", nil
+	}
+
+	var data bytes.Buffer
+	if err := jpeg.Encode(&data, inlineImage(img, key, cols, rows), &jpeg.Options{Quality: 90}); err != nil {
+		return "", fmt.Errorf("encoding image for iTerm2: %w", err)
+	}
+
+	return anchored(cols, rows, ansi.ITerm2(iterm2.File{
+		Name:    "apod.jpg",
+		Size:    int64(data.Len()),
+		Width:   iterm2.Cells(cols),
+		Height:  iterm2.Cells(rows),
+		Inline:  true,
+		Content: []byte(base64.StdEncoding.EncodeToString(data.Bytes())),
+	})), nil
+}
+
+// anchored lays out an image that the terminal draws over cols x rows cells
+// when it receives seq. The image is laid over whatever cells it covers, and
+// anything written over those cells afterwards erases that part of it. Bubble
+// Tea paints top to bottom, so seq is sent from an extra line below the
+// image, after the blank lines it covers have been painted, with the cursor
+// moved up and restored around it. That line itself stays blank.
+func anchored(cols, rows int, seq string) string {
+	blank := strings.Repeat(" ", cols)
+	var s strings.Builder
+	for range rows {
+		s.WriteString(blank)
+		s.WriteString("\n")
+	}
+	s.WriteString(ansi.SaveCursor)
+	s.WriteString(ansi.CursorUp(rows))
+	s.WriteString(seq)
+	s.WriteString(ansi.RestoreCursor)
+	s.WriteString(blank)
+	return s.String()
+}
+
+// renderInline renders with an image protocol, falling back to ASCII if the
+// image can't be encoded.
+func renderInline(img image.Image, key frameKey) string {
+	var frame string
+	var err error
+	switch key.mode {
+	case RenderKitty:
+		frame, err = renderKitty(img, key)
+	case RenderITerm2:
+		frame, err = renderITerm2(img, key)
+	case RenderSixel:
+		frame = renderSixel(img, key)
+	}
+	if err != nil {
+		slog.Warn("falling back to ASCII", "mode", key.mode, "error", err)
+		return renderASCII(img, key)
+	}
+	return frame
+}
@@ -0,0 +1,98 @@
+package airlockspace
+
+import (
+	"image"
+	"image/color"
+	"strings"
+
+	"github.com/muesli/termenv"
+	"github.com/nfnt/resize"
+)
+
+// renderHalfBlock draws img with "▀" characters, using the foreground color
+// for the top pixel and the background color for the bottom one. That gives
+// two pixels per cell, which with cells being about twice as tall as they are
+// wide makes the pixels roughly square.
+func renderHalfBlock(img image.Image, key frameKey) string {
+	profile := key.profile
+	imageWidth, imageHeight := fitImage(img.Bounds().Dx(), img.Bounds().Dy(), key.width, key.height*2, key.aspect*2)
+	if imageWidth == 0 || imageHeight == 0 {
+		return ""
+	}
+	scaled := resize.Resize(uint(imageWidth), uint(imageHeight), img, resize.Bilinear)
+	var colors [][]termenv.Color
+	if profile != termenv.Ascii {
+		colors = quantize(scaled, profile, key.dither)
+	}
+
+	var s strings.Builder
+	for y := 0; y < imageHeight; y += 2 {
+		if y > 0 {
+			s.WriteString("\n")
+		}
+		var last string
+		for x := 0; x < imageWidth; x++ {
+			top := scaled.At(x, y)
+			var bottom color.Color
+			if y+1 < imageHeight {
+				bottom = scaled.At(x, y+1)
+			}
+
+			if profile == termenv.Ascii {
+				s.WriteString(halfBlockMono(top, bottom))
+				continue
+			}
+
+			var seq string
+			if bottom != nil {
+				seq = sequence(colors[y][x], false) + sequence(colors[y+1][x], true)
+			} else {
+				// odd last row: leave the bottom half as the terminal background
+				seq = resetSequence + sequence(colors[y][x], false)
+			}
+			if seq != last {
+				s.WriteString(seq)
+				last = seq
+			}
+			s.WriteString("▀")
+		}
+		if profile != termenv.Ascii {
+			s.WriteString(resetSequence)
+		}
+	}
+	return s.String()
+}
+
+// halfBlockMono picks the block character that lights up whichever of the
+// two pixels are bright, for terminals without color.
+func halfBlockMono(top, bottom color.Color) string {
+	t := luminance(top) > 0.5
+	b := bottom != nil && luminance(bottom) > 0.5
+	switch {
+	case t && b:
+		return "█"
+	case t:
+		return "▀"
+	case b:
+		return "▄"
+	}
+	return " "
+}
+
+var resetSequence = termenv.CSI + termenv.ResetSeq + "m"
+
+// sequence returns the escape sequence that sets the foreground (or
+// background) to c.
+func sequence(c termenv.Color, bg bool) string {
+	seq := c.Sequence(bg)
+	if seq == "" {
+		return ""
+	}
+	return termenv.CSI + seq + "m"
+}
+
+// luminance returns the perceived brightness of c between 0 and 1.
+func luminance(c color.Color) float64 {
+	r, g, b, _ := c.RGBA()
+	return (0.2126*float64(r) + 0.7152*float64(g) + 0.0722*float64(b)) / 0xffff
+}
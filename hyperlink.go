@@ -0,0 +1,69 @@
+package airlockspace
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// DetectHyperlinks reports whether the terminal turns OSC 8 sequences into
+// clickable links, judging by its TERM and environment variables. Terminals
+// that don't support them are supposed to ignore them, but some print them.
+func DetectHyperlinks(term string, environ []string) bool {
+	switch strings.ToLower(term) {
+	case "xterm-kitty", "xterm-ghostty", "wezterm", "foot", "foot-extra", "alacritty", "contour":
+		return true
+	}
+	for _, env := range environ {
+		name, value, _ := strings.Cut(env, "=")
+		switch name {
+		case "TERM_PROGRAM", "LC_TERMINAL":
+			switch strings.ToLower(value) {
+			case "iterm2", "wezterm", "vscode", "hyper", "ghostty":
+				return true
+			}
+		case "VTE_VERSION":
+			// GNOME Terminal and friends, since VTE 0.50
+			if v, err := strconv.Atoi(value); err == nil && v >= 5000 {
+				return true
+			}
+		case "WT_SESSION", "KITTY_WINDOW_ID":
+			return true
+		}
+	}
+	return false
+}
+
+// hyperlink makes s a link to url if the terminal supports it. Every line of
+// s is linked on its own, without the spaces around it: Bubble Tea repaints
+// lines independently, so a link can't be left open across them.
+func (m *Model) hyperlink(s, url string) string {
+	if !m.Hyperlinks || url == "" {
+		return s
+	}
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		plain := ansi.Strip(line)
+		text := strings.TrimSpace(plain)
+		if text == "" {
+			continue
+		}
+		left := ansi.StringWidth(plain[:strings.Index(plain, text)])
+		right := left + ansi.StringWidth(text)
+		lines[i] = ansi.Truncate(line, left, "") +
+			ansi.SetHyperlink(url) + ansi.Cut(line, left, right) + ansi.ResetHyperlink() +
+			ansi.TruncateLeft(line, right, "")
+	}
+	return strings.Join(lines, "\n")
+}
+
+var urlRegex = regexp.MustCompile(`https?://[^\s<>"]+`)
+
+// linkURLs turns the URLs in s into links to themselves.
+func (m *Model) linkURLs(s string) string {
+	return urlRegex.ReplaceAllStringFunc(s, func(url string) string {
+		return m.hyperlink(url, url)
+	})
+}
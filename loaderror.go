@@ -0,0 +1,82 @@
+package airlockspace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/kamaln7/airlock.space/apod"
+	"github.com/muesli/reflow/wordwrap"
+)
+
+// A failed load is retried after loadBackoff, doubling with every failure in
+// a row up to maxLoadBackoff, so that a rate limited or unreachable API isn't
+// asked again on every message.
+const (
+	loadBackoff    = 5 * time.Second
+	maxLoadBackoff = 5 * time.Minute
+)
+
+type loadRetryMsg struct {
+	date    time.Time
+	attempt int // loadRetries when it was scheduled
+}
+
+// loaded records how the load of the APOD went, and schedules a retry if it
+// failed for a reason that may go away.
+func (m *Model) loaded(err error) tea.Cmd {
+	m.loadErr, m.loadRetryAt = nil, time.Time{}
+	if m.apod != nil {
+		m.loadRetries = 0
+		return nil
+	}
+	if err == nil {
+		err = errors.New("no APOD")
+	}
+	m.loadErr = err
+	if errors.Is(err, apod.ErrNotFound) || errors.Is(err, apod.ErrBeforeArchive) || errors.Is(err, apod.ErrFutureDate) {
+		return nil
+	}
+
+	delay := min(loadBackoff<<m.loadRetries, maxLoadBackoff)
+	m.loadRetries++
+	m.loadRetryAt = time.Now().Add(delay)
+	msg := loadRetryMsg{date: m.date, attempt: m.loadRetries}
+	return tea.Tick(delay, func(time.Time) tea.Msg {
+		return msg
+	})
+}
+
+// describeLoadError turns an APOD fetch error into something a visitor can
+// make sense of.
+func describeLoadError(err error) string {
+	switch {
+	case errors.Is(err, apod.ErrNotFound):
+		return "there is no APOD for this day"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "the request timed out"
+	}
+	return err.Error()
+}
+
+func (m *Model) viewLoadError() string {
+	width := min(80, m.Width-2) // -2 for the margin
+	msg := fmt.Sprintf("⚠ couldn't load the APOD for %s: %s", m.currentDate().Format(time.DateOnly), describeLoadError(m.loadErr))
+	if !m.loadRetryAt.IsZero() {
+		msg += fmt.Sprintf(" • retrying in %s", max(0, time.Until(m.loadRetryAt)).Round(time.Second))
+	}
+	if m.randomErr != nil {
+		msg += "\n⚠ no random pick: " + describeLoadError(m.randomErr)
+	}
+	return lipgloss.Place(m.Width, m.Height, lipgloss.Center, lipgloss.Center,
+		lipgloss.JoinVertical(lipgloss.Center,
+			m.txtYellow().Render(wordwrap.String(msg, width)),
+			m.viewHelpWrapped(width, slices.Concat(m.dayKeys(), []key.Binding{keyReload, keyQuit})...),
+		),
+	)
+}
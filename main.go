@@ -1,30 +1,33 @@
 package airlockspace
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"image"
 	_ "image/jpeg"
 	_ "image/png"
 	"log/slog"
 	"math"
-	"regexp"
+	"net"
 	"slices"
 	"strings"
 	"time"
 	"unicode"
-	"unicode/utf8"
 
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 	"github.com/kamaln7/airlock.space/apod"
 	"github.com/muesli/reflow/wordwrap"
-	"github.com/qeesung/image2ascii/convert"
+	"github.com/muesli/termenv"
 	"github.com/samber/lo"
 	lom "github.com/samber/lo/mutable"
 )
 
-var ansiRegex = regexp.MustCompile("\x1b\\[[0-9;]*[a-zA-Z]")
 var (
 	colorMuted      = lipgloss.AdaptiveColor{Light: "#9B9B9B", Dark: "#5C5C5C"}
 	colorSuperMuted = lipgloss.AdaptiveColor{Light: "#DDDADA", Dark: "#3C3C3C"}
@@ -35,13 +38,51 @@ var (
 
 // Just a generic tea.Model to demo terminal information of ssh.
 type Model struct {
-	Width            int
-	Height           int
-	Style            lipgloss.Style
-	State            State
-	imgOrExplanation bool // true -> img, false -> explanation
-	apod             *apod.APOD
-	reloadedRecently bool
+	Width             int
+	Height            int
+	Style             lipgloss.Style
+	Profile           termenv.Profile // color profile of the client terminal
+	RenderMode        RenderMode
+	Graphics          []RenderMode   // image protocols the terminal supports, see DetectGraphics
+	Dither            Dither         // for when the terminal has few colors
+	CellAspect        float64        // cell width / height, see cellAspect
+	CellSize          image.Point    // cell size in pixels, if the terminal reported it
+	Hyperlinks        bool           // whether the terminal supports OSC 8, see DetectHyperlinks
+	Term              string         // TERM of the client terminal
+	Slideshow         bool           // start in StateSlideshow
+	SlideshowOrder    SlideshowOrder // which APODs the slideshow goes through
+	SlideshowInterval time.Duration  // how long a slide is shown for, 30s if zero
+	State             State
+	Archive           *apod.Archive // defaults to apod.Default
+	imgOrExplanation  bool          // true -> img, false -> explanation
+	apod              *apod.APOD
+	date              time.Time // zero -> today
+	image             image.Image
+	imageErr          error
+	frame             string      // last rendered image
+	frameKey          frameKey    // what frame was rendered for
+	pendingFrame      frameKey    // frame being rendered in the background
+	pendingImage      image.Image // what pendingFrame is a frame of
+	inlineOnScreen    frameKey    // frame drawn by the terminal that is on screen
+	zoom              zoom        // part of the image shown in fullscreen
+	hdImage           image.Image
+	hdState           hdState
+	download          download         // the download the view is waiting on
+	explanation       viewport.Model   // scrolls the explanation, see explanationLayout
+	helpShown         *[][]key.Binding // collects the help lines rendered, see helpZones
+	dragging          bool             // panning fullscreen with the mouse
+	dragAt            image.Point      // where the pointer was last seen while dragging
+	reloadedRecently  bool
+	copiedRecently    bool        // "copied!" is showing, see copy
+	clipboard         string      // OSC 52 sequence the view carries, see copy
+	calendar          calendar    // the date picker, see viewCalendar
+	pickingRandom     bool        // waiting on a randomMsg
+	randomErr         error       // why the last random pick failed, see showRandom
+	history           []time.Time // days left for random picks, see back
+	slideshow         slideshow
+	loadErr           error     // why the APOD didn't load
+	loadRetries       int       // failed loads of the day in a row, for backoff
+	loadRetryAt       time.Time // when the next retry is due, zero if none
 }
 
 type State int
@@ -51,73 +92,377 @@ const (
 	StateAPOD
 	StateLink
 	StateFullscreen
+	StateVideo      // a video APOD without a thumbnail to show
+	StateImageError // the APOD loaded but its image didn't
+	StateCalendar   // picking a day to load
+	StateSlideshow  // cycling through APODs in fullscreen
+	StateLoadError  // the APOD didn't load, see loaded
 )
 
 func (m *Model) Init() tea.Cmd {
 	m.imgOrExplanation = true
-	return nil
+	m.explanation = newExplanation()
+	cmd := m.loadAPOD(m.date)
+	if m.Slideshow {
+		return tea.Batch(cmd, m.startSlideshow())
+	}
+	return cmd
 }
 
 func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
-	if m.apod == nil {
-		cmds = append(cmds, m.loadAPOD())
-	}
 
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		m.Height = msg.Height
 		m.Width = msg.Width
 	case tea.KeyMsg:
-		switch {
-		case key.Matches(msg, keyQuit):
-			return m, tea.Quit
-		case key.Matches(msg, keyReload):
-			m.reloadedRecently = true
-			m.State = StateLoading
-			cmds = append(cmds, m.loadAPOD())
-		case key.Matches(msg, keyExplanation):
-			m.State = StateAPOD
-			m.imgOrExplanation = !m.imgOrExplanation
-		case key.Matches(msg, keyLink):
-			if m.State == StateLink {
-				m.State = StateAPOD
-			} else {
-				m.State = StateLink
-			}
-		case key.Matches(msg, keyFullscreen):
-			if m.State == StateFullscreen {
-				m.State = StateAPOD
-			} else {
-				m.State = StateFullscreen
-			}
-		}
+		cmds = append(cmds, m.keyPress(msg))
 	case apodMsg:
-		m.apod = msg
-		m.State = StateAPOD
+		if !msg.date.Equal(m.date) {
+			// a navigation happened since this was requested
+			break
+		}
+		m.apod = msg.apod
+		cmds = append(cmds, m.loaded(msg.err))
+		m.explanation.GotoTop()
+		m.image, m.imageErr = nil, nil
+		m.hdImage, m.hdState = nil, hdNone
+		m.download = download{}
+		m.pendingFrame, m.pendingImage = frameKey{}, nil
+		m.zoom = zoom{}
+		if m.apod != nil && m.date.IsZero() {
+			m.date = apod.Day(m.apod.ApodDate)
+		}
+		if m.apod != nil && m.apod.HasImage() {
+			cmds = append(cmds, m.loadImage(m.apod))
+		} else if m.apod != nil && !m.apod.IsVideo() {
+			// a media type other than image or video, or an image day
+			// without a URL
+			m.imageErr = apod.ErrNoImage
+		}
+		if !m.stateStays() {
+			m.State = m.mainState()
+		}
 		cmds = append(cmds, tea.Tick(time.Second*5, func(t time.Time) tea.Msg {
 			m.reloadedRecently = false
 			return msgRerender{}
 		}))
+	case imageMsg:
+		if msg.apod != m.apod {
+			break
+		}
+		m.image, m.imageErr = msg.image, msg.err
+		m.download = download{}
+		m.pendingFrame, m.pendingImage = frameKey{}, nil
+		if !m.stateStays() {
+			m.State = m.mainState()
+		}
+	case hdMsg:
+		if msg.apod != m.apod {
+			break
+		}
+		m.download = download{}
+		switch {
+		case msg.image == nil:
+			m.hdState = hdFailed
+		case m.State == StateFullscreen:
+			m.hdImage, m.hdState = msg.image, hdLoaded
+		default:
+			// left fullscreen while it loaded; decoded again if it's back
+			m.hdState = hdNone
+		}
+	case loadRetryMsg:
+		if m.State == StateLoadError && msg.date.Equal(m.date) && msg.attempt == m.loadRetries {
+			m.State = StateLoading
+			cmds = append(cmds, m.loadAPOD(m.date))
+		}
+	case slideTickMsg:
+		cmds = append(cmds, m.slideTick(msg))
+	case slideMsg:
+		cmds = append(cmds, m.slideReady(msg))
+	case randomMsg:
+		if m.pickingRandom {
+			cmds = append(cmds, m.showRandom(msg))
+		}
+	case randomErrExpiredMsg:
+		if m.randomErr == msg.err {
+			m.randomErr = nil
+		}
+	case copiedExpiredMsg:
+		m.copiedRecently, m.clipboard = false, ""
+	case tea.MouseMsg:
+		cmds = append(cmds, m.mouse(msg))
+	case progressMsg:
+		if msg.progress == m.download.progress {
+			m.download = download(msg)
+			cmds = append(cmds, waitProgress(m.download))
+		}
+	case renderMsg:
+		cmds = append(cmds, m.renderFrame(msg.key))
+	case frameMsg:
+		if msg.key == m.pendingFrame {
+			m.frame, m.frameKey = msg.frame, msg.key
+			m.pendingFrame, m.pendingImage = frameKey{}, nil
+		}
+	}
+	if m.explanationShown() {
+		switch msg.(type) {
+		case tea.KeyMsg, tea.MouseMsg:
+			m.explanationLayout()
+			var cmd tea.Cmd
+			m.explanation, cmd = m.explanation.Update(msg)
+			cmds = append(cmds, cmd)
+		}
+	}
+	if m.State != StateFullscreen && m.hdState == hdLoaded {
+		// only fullscreen shows it, and decoded it is big; the bytes are kept
+		m.hdImage, m.hdState = nil, hdNone
+	}
+	if m.needsHD() {
+		m.hdState = hdLoading
+		cmds = append(cmds, m.loadHD(m.apod))
+	}
+	cmds = append(cmds, m.requestFrame())
+
+	// Images drawn by the terminal stay on screen until the cells under them
+	// are rewritten, and Bubble Tea only rewrites lines that changed.
+	if shown := m.inlineShown(); shown != m.inlineOnScreen {
+		if m.inlineOnScreen != (frameKey{}) {
+			cmds = append(cmds, tea.ClearScreen)
+		}
+		m.inlineOnScreen = shown
 	}
 	return m, tea.Batch(cmds...)
 }
 
+// keyPress does what msg's key is bound to in the current state.
+func (m *Model) keyPress(msg tea.KeyMsg) tea.Cmd {
+	switch {
+	case key.Matches(msg, keyQuit):
+		return tea.Quit
+	case m.State == StateCalendar:
+		return m.calendarKey(msg)
+	case m.State == StateSlideshow:
+		return m.slideshowKey(msg)
+	case key.Matches(msg, keySlideshow):
+		return m.startSlideshow()
+	case key.Matches(msg, keyCalendar):
+		m.openCalendar()
+	case m.State == StateFullscreen && key.Matches(msg, keyZoomIn):
+		m.zoom = m.zoom.zoomBy(1)
+	case m.State == StateFullscreen && key.Matches(msg, keyZoomOut):
+		m.zoom = m.zoom.zoomBy(-1)
+	case m.State == StateFullscreen && key.Matches(msg, keyZoomReset):
+		m.zoom = zoom{}
+	case m.State == StateFullscreen && m.zoom.level > 0 && key.Matches(msg, keyPan):
+		d := panDirections[msg.String()]
+		m.pan(float64(d.X)*panStep, float64(d.Y)*panStep)
+	case key.Matches(msg, keyReload):
+		m.reloadedRecently = true
+		m.State = StateLoading
+		return m.loadAPOD(m.date)
+	case key.Matches(msg, keyPrev):
+		return m.navigate(-1)
+	case key.Matches(msg, keyNext):
+		return m.navigate(1)
+	case key.Matches(msg, keyRandom):
+		return m.loadRandom()
+	case key.Matches(msg, keyBack):
+		return m.back()
+	case key.Matches(msg, keyExplanation):
+		m.State = m.mainState()
+		m.imgOrExplanation = !m.imgOrExplanation
+	case key.Matches(msg, keyLink):
+		if m.State == StateLink {
+			m.State = m.mainState()
+		} else {
+			m.State = StateLink
+		}
+	case key.Matches(msg, keyFullscreen):
+		if m.State == StateFullscreen {
+			m.State = m.mainState()
+		} else if m.image != nil {
+			m.State = StateFullscreen
+		}
+	case key.Matches(msg, keyRender):
+		modes := slices.Concat(renderModes, m.Graphics)
+		m.RenderMode = modes[(slices.Index(modes, m.RenderMode)+1)%len(modes)]
+	case key.Matches(msg, keyCopy):
+		return m.copy(m.apodURL())
+	case key.Matches(msg, keyCopyDetails):
+		return m.copy(m.copyDetails())
+	case key.Matches(msg, keyRetryImage):
+		if m.State == StateImageError && !errors.Is(m.imageErr, apod.ErrNoImage) {
+			m.imageErr = nil
+			m.State = m.mainState()
+			return m.loadImage(m.apod)
+		}
+	}
+	return nil
+}
+
 type msgRerender struct{}
 
-type apodMsg *apod.APOD
+type apodMsg struct {
+	date time.Time
+	apod *apod.APOD
+	err  error // why apod is nil, if it is
+}
 
-func (m *Model) loadAPOD() tea.Cmd {
-	return func() tea.Msg {
-		apod, err := apod.Today()
+type imageMsg struct {
+	apod  *apod.APOD
+	image image.Image
+	err   error
+}
+
+// loadImage downloads, decodes and downscales a's image in the background.
+func (m *Model) loadImage(a *apod.APOD) tea.Cmd {
+	done := make(chan struct{})
+	return tea.Batch(func() tea.Msg {
+		defer close(done)
+		img, err := a.ImagePreview()
+		if err != nil {
+			slog.Warn("failed to get image decoded", "date", a.Date, "error", err)
+			// Graceful resolvables hand back the last good image alongside
+			// the error, which is still worth showing.
+			if img != nil {
+				err = nil
+			}
+		}
+		return imageMsg{apod: a, image: img, err: err}
+	}, m.watchDownload(&a.ImageProgress, done))
+}
+
+// hdState tracks the HD image of the APOD being shown.
+type hdState int
+
+const (
+	hdNone hdState = iota // not needed yet
+	hdLoading
+	hdLoaded
+	hdFailed // too large, missing, or broken; not tried again
+)
+
+type hdMsg struct {
+	apod  *apod.APOD
+	image image.Image
+}
+
+// loadHD downloads and decodes a's HD image in the background.
+func (m *Model) loadHD(a *apod.APOD) tea.Cmd {
+	done := make(chan struct{})
+	return tea.Batch(func() tea.Msg {
+		defer close(done)
+		img, err := a.ImageHD()
 		if err != nil {
-			slog.Warn("failed to get APOD", "error", err)
-			if apod == nil {
-				slog.Error("no valid APOD to fallback to", "error", err)
+			slog.Warn("failed to get HD image", "date", a.Date, "error", err)
+			img = nil
+		}
+		return hdMsg{apod: a, image: img}
+	}, m.watchDownload(&a.HDProgress, done))
+}
+
+// needsHD reports whether fullscreen shows the image at a higher resolution
+// than the preview has, so the HD image is worth fetching.
+func (m *Model) needsHD() bool {
+	if m.State != StateFullscreen || m.hdState != hdNone || m.image == nil || !m.apod.HasHD() {
+		return false
+	}
+	width, height := m.fullscreenBox()
+	key := m.frameKeyFor(width, height)
+	bounds := m.image.Bounds()
+	cols, _ := fitImage(bounds.Dx(), bounds.Dy(), width, height, key.aspect)
+	return float64(cols*key.resolution().X)*m.zoom.scale() > float64(bounds.Dx())
+}
+
+// loadAPOD fetches the APOD for date, or today's if date is zero.
+func (m *Model) loadAPOD(date time.Time) tea.Cmd {
+	return func() tea.Msg {
+		archive := m.archive()
+		if date.IsZero() || date.Equal(apod.Day(time.Now())) {
+			a, err := archive.Today()
+			if err != nil {
+				slog.Warn("failed to get APOD", "error", err)
+				if a == nil {
+					slog.Error("no valid APOD to fallback to", "error", err)
+				}
 			}
+			return apodMsg{date: date, apod: a, err: err}
+		}
+
+		a, err := archive.ForDate(context.Background(), date)
+		if err != nil {
+			slog.Warn("failed to get APOD", "date", date.Format(time.DateOnly), "error", err)
+		}
+		return apodMsg{date: date, apod: a, err: err}
+	}
+}
+
+func (m *Model) archive() *apod.Archive {
+	if m.Archive == nil {
+		return apod.Default
+	}
+	return m.Archive
+}
+
+// navigate moves the current date by the given number of days.
+func (m *Model) navigate(days int) tea.Cmd {
+	return m.goTo(m.currentDate().AddDate(0, 0, days))
+}
+
+// goTo loads the APOD for date, refusing to go past today or before the
+// start of the archive.
+func (m *Model) goTo(date time.Time) tea.Cmd {
+	if date.After(apod.Day(time.Now())) || date.Before(apod.ArchiveStart) {
+		return nil
+	}
+
+	m.date = date
+	m.State = StateLoading
+	m.pickingRandom = false
+	m.loadRetries = 0
+	m.download = download{}
+	return m.loadAPOD(date)
+}
+
+// pan moves the zoomed in view in fullscreen by dx, dy times its size.
+func (m *Model) pan(dx, dy float64) {
+	width, height := m.fullscreenBox()
+	key := m.frameKeyFor(width, height)
+	m.zoom = m.zoom.pan(dx, dy, m.imageFor(key).Bounds(), key.crop)
+}
+
+// stateStays reports whether the state is one the user picked that an APOD
+// or its image arriving shouldn't take them out of.
+func (m *Model) stateStays() bool {
+	return m.State == StateLink || m.State == StateCalendar || m.State == StateSlideshow
+}
+
+// mainState is the state to return to once the APOD is loaded: videos
+// without a thumbnail get their own panel since there is no image to show.
+func (m *Model) mainState() State {
+	if m.apod == nil {
+		if m.loadErr != nil {
+			return StateLoadError
 		}
-		return apodMsg(apod)
+		return StateLoading
+	}
+	if m.apod.IsVideo() && !m.apod.HasImage() {
+		return StateVideo
+	}
+	if m.imageErr != nil {
+		return StateImageError
+	}
+	return StateAPOD
+}
+
+// currentDate is the day being shown, resolving the zero value to today.
+func (m *Model) currentDate() time.Time {
+	if m.date.IsZero() {
+		return apod.Day(time.Now())
 	}
+	return m.date
 }
 
 var (
@@ -141,9 +486,55 @@ var (
 		key.WithKeys("f", "ctrl+f"),
 		key.WithHelp("f", "fullscreen"),
 	)
+	keyPrev = key.NewBinding(
+		key.WithKeys("left", "p"),
+		key.WithHelp("←/p", "prev day"),
+	)
+	keyNext = key.NewBinding(
+		key.WithKeys("right", "n"),
+		key.WithHelp("→/n", "next day"),
+	)
+	keyRender = key.NewBinding(
+		key.WithKeys("m"),
+		key.WithHelp("m", "render mode"),
+	)
+	keyRetryImage = key.NewBinding(
+		key.WithKeys("i"),
+		key.WithHelp("i", "retry image"),
+	)
+	keyZoomIn = key.NewBinding(
+		key.WithKeys("+", "="),
+		key.WithHelp("+", "zoom in"),
+	)
+	keyZoomOut = key.NewBinding(
+		key.WithKeys("-"),
+		key.WithHelp("-", "zoom out"),
+	)
+	keyZoomReset = key.NewBinding(
+		key.WithKeys("0"),
+		key.WithHelp("0", "whole image"),
+	)
+	keyPan = key.NewBinding(
+		key.WithKeys("left", "down", "up", "right", "h", "j", "k", "l"),
+		key.WithHelp("←↓↑→/hjkl", "pan"),
+	)
 )
 
+// panDirections maps keyPan's keys to the direction they pan in.
+var panDirections = map[string]image.Point{
+	"left": {-1, 0}, "h": {-1, 0},
+	"down": {0, 1}, "j": {0, 1},
+	"up": {0, -1}, "k": {0, -1},
+	"right": {1, 0}, "l": {1, 0},
+}
+
+// View renders the current state, along with a pending copy for the
+// terminal.
 func (m *Model) View() string {
+	return m.view() + m.clipboard
+}
+
+func (m *Model) view() string {
 	switch m.State {
 	case StateLoading:
 		return m.viewLoading()
@@ -153,46 +544,40 @@ func (m *Model) View() string {
 		return m.viewLink()
 	case StateFullscreen:
 		return m.viewFullscreen()
+	case StateVideo:
+		return m.viewVideo()
+	case StateImageError:
+		return m.viewImageError()
+	case StateCalendar:
+		return m.viewCalendar()
+	case StateSlideshow:
+		return m.viewSlideshow()
+	case StateLoadError:
+		return m.viewLoadError()
 	}
 	return "error"
 }
 
-func (m *Model) viewAPOD() string {
-	totalWidth := m.Width - 2 // -2 for the margin
-	apodWidth := min(60, totalWidth)
-	freeWidth := totalWidth - apodWidth
-	if m.imgOrExplanation {
-		// full-width apod if we are showing the image
-		apodWidth = totalWidth
-		freeWidth = totalWidth
-	}
-	apodView := (&apodView{
-		apod:             m.apod,
-		style:            m.Style,
-		reloadedRecently: m.reloadedRecently,
-		width:            apodWidth,
-		txtMuted:         m.txtMuted,
-		txtYellow:        m.txtYellow,
-		divDot:           m.divDot,
-		writeExplanation: !m.imgOrExplanation,
-	}).View()
-	helpView := m.viewHelp()
+// imageLayout renders what surrounds the image in StateAPOD and returns it
+// along with the size left over for the image itself.
+func (m *Model) imageLayout() (header, help string, width, height int) {
+	width = m.Width - 2 // -2 for the margin
+	header = m.apodView(width, false).View()
+	help = m.viewHelpWrapped(width, m.mainKeys()...)
+	height = m.Height - 3 - countLines(help) - countLines(header) // -3 for the margins
+	return header, help, width, height
+}
 
-	freeHeight := m.Height - 3 - countLines(helpView) // -3 for the margins
-	if m.imgOrExplanation {
-		freeHeight -= countLines(apodView)
-		image, err := m.apod.ImageDecoded()
-		if err != nil {
-			slog.Error("failed to get image decoded", "error", err)
+func (m *Model) viewAPOD() string {
+	if m.apod != nil && m.imgOrExplanation {
+		apodView, helpView, freeWidth, freeHeight := m.imageLayout()
+		asciiImage := m.txtYellow().Render("✨ loading image...")
+		if download := m.viewDownload(freeWidth); download != "" {
+			asciiImage = lipgloss.JoinVertical(lipgloss.Center, asciiImage, "", download)
+		}
+		if m.image != nil {
+			asciiImage = m.viewImage(freeWidth, freeHeight)
 		}
-		converter := convert.NewImageConverter()
-
-		imageWidth, imageHeight := fitImage(image.Bounds().Dx(), image.Bounds().Dy(), freeWidth, freeHeight)
-		asciiImage := converter.Image2ASCIIString(image, &convert.Options{
-			Colored:     true,
-			FixedWidth:  imageWidth,
-			FixedHeight: imageHeight,
-		})
 		return m.Style.Margin(1, 1).Render(
 			lipgloss.JoinVertical(lipgloss.Left,
 				apodView,
@@ -200,51 +585,186 @@ func (m *Model) viewAPOD() string {
 				helpView,
 			),
 		)
-	} else {
-		// find ascii art fitting the free width and height
-		var asciiArt string
-		allAsciiArt := slices.Clone(ASCIIAll)
-		lom.Shuffle(allAsciiArt)
-		for _, art := range allAsciiArt {
-			if countLines(art) > freeHeight {
-				continue
-			}
-			longestLine := lenLongest(strings.Split(art, "\n")...)
-			if longestLine > freeWidth {
-				continue
-			}
-			asciiArt = colorize(m.Style, art, colorMuted, colorCosmic, colorStellar, colorNebula)
-			break
+	}
+
+	totalWidth := m.Width - 2 // -2 for the margin
+	apodWidth := min(60, totalWidth)
+	freeWidth := totalWidth - apodWidth
+	apodView := m.apodView(apodWidth, false).View()
+	helpView := m.viewHelpWrapped(apodWidth, m.mainKeys()...)
+
+	freeHeight := m.Height - 3 - countLines(helpView) // -3 for the margins
+	if m.apod == nil {
+		return m.Style.Margin(1, 1).Render(apodView + helpView)
+	}
+
+	// find ascii art fitting the free width and height
+	var asciiArt string
+	allAsciiArt := slices.Clone(ASCIIAll)
+	lom.Shuffle(allAsciiArt)
+	for _, art := range allAsciiArt {
+		if countLines(art) > freeHeight {
+			continue
 		}
+		longestLine := lenLongest(strings.Split(art, "\n")...)
+		if longestLine > freeWidth {
+			continue
+		}
+		asciiArt = colorize(m.Style, art, colorMuted, colorCosmic, colorStellar, colorNebula)
+		break
+	}
 
-		return m.Style.Margin(1, 1).Render(
-			lipgloss.JoinHorizontal(lipgloss.Top,
-				apodView+helpView,
-				m.Style.Width(freeWidth).Height(freeHeight).Align(lipgloss.Center, lipgloss.Center).Render(asciiArt),
-			),
-		)
+	left := apodView + helpView
+	if m.explanationShown() {
+		left = m.viewExplanation()
 	}
+	return m.Style.Margin(1, 1).Render(
+		lipgloss.JoinHorizontal(lipgloss.Top,
+			left,
+			m.Style.Width(freeWidth).Height(freeHeight).Align(lipgloss.Center, lipgloss.Center).Render(asciiArt),
+		),
+	)
 }
 
-func (m *Model) viewLink() string {
-	helpView := m.viewHelp()
-	return m.txtYellow().Width(m.Width).Height(m.Height).Align(lipgloss.Center, lipgloss.Center).Render(
-		"🔗 link to APOD:\n\n" + fmt.Sprintf("https://apod.nasa.gov/apod/ap%s.html", m.apod.ApodDate.Format("060102")) + "\n" + helpView,
+func (m *Model) viewVideo() string {
+	width := min(80, m.Width-2) // -2 for the margin
+	apodView := m.apodView(width, true).View()
+
+	var s strings.Builder
+	s.WriteString(m.txtMuted().Render("🎬 video of the day — watch it at:"))
+	s.WriteString("\n")
+	s.WriteString(m.hyperlink(m.txtYellow().Render(m.apod.URL), m.apod.URL))
+
+	return m.Style.Margin(1, 1).Render(
+		lipgloss.JoinVertical(lipgloss.Left,
+			apodView,
+			s.String(),
+			m.viewHelpWrapped(width, append(m.dayKeys(), keyLink, keyReload, keyQuit)...),
+		),
 	)
 }
 
+func (m *Model) viewImageError() string {
+	width := min(80, m.Width-2) // -2 for the margin
+	apodView := m.apodView(width, true).View()
+
+	reason := m.txtYellow().Render(wordwrap.String("⚠ couldn't load the image: "+describeImageError(m.imageErr), width))
+	keys := slices.Concat(m.dayKeys(), []key.Binding{keyLink, keyReload, keyQuit})
+	if !errors.Is(m.imageErr, apod.ErrNoImage) {
+		// there's nothing to retry without an image to download
+		keys = slices.Concat([]key.Binding{keyRetryImage}, keys)
+	}
+
+	return m.Style.Margin(1, 1).Render(
+		lipgloss.JoinVertical(lipgloss.Left,
+			apodView,
+			reason,
+			m.viewHelpWrapped(width, keys...),
+		),
+	)
+}
+
+// describeImageError turns an image fetch error into something a visitor can
+// make sense of.
+func describeImageError(err error) string {
+	var statusErr *apod.StatusError
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded),
+		errors.As(err, &netErr) && netErr.Timeout():
+		return "the download timed out"
+	case errors.As(err, &statusErr):
+		return fmt.Sprintf("the server responded with HTTP %s", statusErr.Status)
+	case errors.Is(err, image.ErrFormat):
+		return "the image is in an unsupported format"
+	case errors.Is(err, apod.ErrNoImage):
+		return "there is no image for this day"
+	}
+	return err.Error()
+}
+
+func (m *Model) viewLink() string {
+	helpView := m.viewHelpWrapped(m.Width, keyPrev, keyNext, keyCopy, keyCopyDetails, keyLink, keyQuit)
+	lines := []string{"🔗 link to APOD:", "", m.hyperlink(m.apodURL(), m.apodURL())}
+	if a := m.apod; a != nil {
+		if a.IsVideo() {
+			lines = append(lines, "", "🎬 video: "+m.hyperlink(a.URL, a.URL))
+		} else if a.URL != "" {
+			lines = append(lines, "", "🖼  image: "+m.hyperlink(a.URL, a.URL))
+		}
+		if a.HasHD() && a.HDURL != a.URL {
+			lines = append(lines, "🔭 HD image: "+m.hyperlink(a.HDURL, a.HDURL))
+		}
+		if credit := strings.Join(strings.Fields(a.Copyright), " "); credit != "" {
+			lines = append(lines, "© "+m.linkURLs(credit))
+		}
+	}
+	var status []string
+	if m.copiedRecently {
+		status = []string{"", "✅ copied!"}
+	}
+	block := func(lines []string) string {
+		return m.txtYellow().Width(m.Width).Align(lipgloss.Center).Render(
+			strings.Join(slices.Concat(lines, status), "\n") + "\n" + helpView,
+		)
+	}
+	// for phones, if there is room for it; long links wrap, so the room is
+	// measured on the block as it will be laid out
+	free := m.Height - lipgloss.Height(block(lines)) - 1
+	if code := m.viewQR(m.apodURL(), m.Width, free); code != "" {
+		lines = append(lines, "", code)
+	}
+	return m.Style.Height(m.Height).AlignVertical(lipgloss.Center).Render(block(lines))
+}
+
+// mainKeys are the keys of StateAPOD.
+func (m *Model) mainKeys() []key.Binding {
+	return append(m.dayKeys(), keyExplanation, keyLink, keyReload, keyFullscreen, keyRender, keyQuit)
+}
+
+// viewHelp renders keys as a single line of help.
 func (m *Model) viewHelp(keys ...key.Binding) string {
-	if len(keys) == 0 {
-		keys = []key.Binding{keyExplanation, keyLink, keyReload, keyFullscreen, keyQuit}
+	return m.viewHelpWrapped(0, keys...)
+}
+
+// viewHelpWrapped renders keys as help, starting a new line wherever an
+// entry would take the line past width. A width of 0 keeps it to one line.
+func (m *Model) viewHelpWrapped(width int, keys ...key.Binding) string {
+	for i, k := range keys {
+		if k.Help().Key == keyRender.Help().Key {
+			keys[i].SetHelp(k.Help().Key, m.RenderMode.String())
+		}
 	}
 	hlp := help.New()
 	hlp.Styles.ShortKey = hlp.Styles.ShortKey.Bold(true)
-	hlpView := hlp.ShortHelpView(keys)
-	return m.Style.MarginTop(1).Render(hlpView)
+	var lines []string
+	for len(keys) > 0 {
+		n := 1
+		for n < len(keys) && (width <= 0 || ansi.StringWidth(hlp.ShortHelpView(keys[:n+1])) <= width) {
+			n++
+		}
+		if m.helpShown != nil {
+			*m.helpShown = append(*m.helpShown, keys[:n])
+		}
+		lines = append(lines, hlp.ShortHelpView(keys[:n]))
+		keys = keys[n:]
+	}
+	return m.Style.MarginTop(1).Render(strings.Join(lines, "\n"))
 }
 
 func (m *Model) viewLoading() string {
-	return m.txtYellow().Width(m.Width).Height(m.Height).Align(lipgloss.Center, lipgloss.Center).Render("✨ loading...")
+	msg := "✨ loading..."
+	if !m.date.IsZero() {
+		msg = fmt.Sprintf("✨ loading %s...", m.date.Format(time.DateOnly))
+	}
+	if m.pickingRandom {
+		msg = "✨ picking a random day..."
+	}
+	view := m.txtYellow().Render(msg)
+	if download := m.viewDownload(m.Width); download != "" {
+		view = lipgloss.JoinVertical(lipgloss.Center, view, "", download)
+	}
+	return lipgloss.Place(m.Width, m.Height, lipgloss.Center, lipgloss.Center, view)
 }
 
 func (m *Model) txtMuted() lipgloss.Style {
@@ -266,11 +786,9 @@ func (m *Model) txtYellow() lipgloss.Style {
 func lenLongest(strs ...string) int {
 	max := 0
 	for _, str := range strs {
-		// Strip ANSI escape sequences before measuring length
-		stripped := ansiRegex.ReplaceAllString(str, "")
-		// Count runes (including emojis) instead of bytes
-		if count := utf8.RuneCountInString(stripped); count > max {
-			max = count
+		// in cells, skipping escape sequences
+		if width := ansi.StringWidth(str); width > max {
+			max = width
 		}
 	}
 	return max
@@ -282,8 +800,13 @@ func countLines(str string) int {
 
 type apodView struct {
 	apod             *apod.APOD
+	date             time.Time
 	style            lipgloss.Style
 	reloadedRecently bool
+	copiedRecently   bool
+	randomErr        error
+	hyperlink        func(s, url string) string // links the title to url
+	url              string
 	width            int
 	writeExplanation bool
 	txtMuted         func() lipgloss.Style
@@ -291,6 +814,26 @@ type apodView struct {
 	divDot           func() lipgloss.Style
 }
 
+// apodView is the header of the APOD shown, width cells wide, followed by
+// its explanation if writeExplanation is set.
+func (m *Model) apodView(width int, writeExplanation bool) *apodView {
+	return &apodView{
+		apod:             m.apod,
+		date:             m.date,
+		style:            m.Style,
+		reloadedRecently: m.reloadedRecently,
+		copiedRecently:   m.copiedRecently,
+		randomErr:        m.randomErr,
+		hyperlink:        m.hyperlink,
+		url:              m.apodURL(),
+		width:            width,
+		writeExplanation: writeExplanation,
+		txtMuted:         m.txtMuted,
+		txtYellow:        m.txtYellow,
+		divDot:           m.divDot,
+	}
+}
+
 func (v *apodView) View() string {
 	txt := v.style
 	var s strings.Builder
@@ -299,21 +842,46 @@ func (v *apodView) View() string {
 	s.WriteString(v.txtMuted().Render("🌌 Astronomy Picture of the Day"))
 	s.WriteString("\n")
 
+	// date
+	date := v.date
+	if date.IsZero() && v.apod != nil {
+		date = v.apod.ApodDate
+	}
+	if !date.IsZero() {
+		s.WriteString(v.txtMuted().Render(date.Format(time.DateOnly)))
+		if date.Equal(apod.Day(time.Now())) {
+			s.WriteString(v.divDot().Render() + v.txtMuted().Render("today"))
+		}
+	}
+
 	// apod
 	if v.apod == nil {
+		s.WriteString("\n")
 		s.WriteString(txt.Render("error fetching APOD :("))
 		s.WriteString("\n")
 		return s.String()
 	}
-	s.WriteString(v.txtMuted().Render(v.apod.ApodDate.Format(time.DateOnly)))
+	if v.apod.IsVideo() {
+		s.WriteString(v.divDot().Render() + v.txtMuted().Render("🎬 video"))
+	}
 	if v.reloadedRecently {
 		s.WriteString(v.divDot().Render() + v.txtYellow().Render("reloaded!"))
 	}
+	if v.copiedRecently {
+		s.WriteString(v.divDot().Render() + v.txtYellow().Render("copied!"))
+	}
+	if v.randomErr != nil {
+		s.WriteString(v.divDot().Render() + v.txtYellow().Render("⚠ no random pick: "+describeLoadError(v.randomErr)))
+	}
 	s.WriteString("\n")
 
 	s.WriteString("\n")
 	s.WriteString("\n")
-	s.WriteString(txt.Width(v.width).Align(lipgloss.Center).Bold(true).Render(v.apod.Title))
+	title := txt.Width(v.width).Align(lipgloss.Center).Bold(true).Render(v.apod.Title)
+	if v.hyperlink != nil {
+		title = v.hyperlink(title, v.url)
+	}
+	s.WriteString(title)
 	s.WriteString("\n")
 	s.WriteString("\n")
 
@@ -339,55 +907,82 @@ func colorize(style lipgloss.Style, str string, colors ...lipgloss.TerminalColor
 }
 
 // fitImage calculates the new dimensions for an image to fit within a container
-// while maintaining the original aspect ratio.
-func fitImage(imageWidth, imageHeight, containerWidth, containerHeight int) (int, int) {
-	if imageWidth <= 0 || imageHeight <= 0 || containerWidth <= 0 || containerHeight <= 0 {
+// while maintaining the original aspect ratio. The container's units are
+// pixelAspect times as wide as they are tall: 1 for pixels, about 0.5 for
+// terminal cells.
+func fitImage(imageWidth, imageHeight, containerWidth, containerHeight int, pixelAspect float64) (int, int) {
+	if imageWidth <= 0 || imageHeight <= 0 || containerWidth <= 0 || containerHeight <= 0 || pixelAspect <= 0 {
 		return 0, 0
 	}
 
+	// The image's height in the container's units
+	height := float64(imageHeight) * pixelAspect
+
 	// Calculate scale factors for both dimensions
 	scaleX := float64(containerWidth) / float64(imageWidth)
-	scaleY := float64(containerHeight) / float64(imageHeight)
+	scaleY := float64(containerHeight) / height
 
 	// Use the smaller scale factor to ensure the image fits within the container
 	scale := math.Min(scaleX, scaleY)
 
 	// Calculate new dimensions
 	newWidth := int(math.Round(float64(imageWidth) * scale))
-	newHeight := int(math.Round(float64(imageHeight) * scale))
+	newHeight := int(math.Round(height * scale))
 
 	return newWidth, newHeight
 }
 
-func (m *Model) viewFullscreen() string {
-	totalWidth := m.Width
-	totalHeight := m.Height
+// fullscreenBox is the size of the image in StateFullscreen and
+// StateSlideshow. Text can't be
+// laid over an image drawn by the terminal, so those frames leave the last
+// line to the help instead of sharing it.
+func (m *Model) fullscreenBox() (width, height int) {
+	if m.RenderMode.inline() {
+		return m.Width, m.Height - 1
+	}
+	return m.Width, m.Height
+}
 
-	image, err := m.apod.ImageDecoded()
-	if err != nil {
-		slog.Error("failed to get image decoded", "error", err)
+func (m *Model) viewFullscreen() string {
+	if m.image == nil {
+		return m.viewAPOD()
 	}
-	converter := convert.NewImageConverter()
 
-	helpView := strings.TrimSpace(m.viewHelp(keyFullscreen))
+	keys := []key.Binding{keyFullscreen, keyZoomIn, keyZoomOut, keySlideshow}
+	if m.zoom.level > 0 {
+		keys = append(keys, keyPan, keyZoomReset)
+	}
+	helpView := strings.TrimSpace(m.viewHelp(keys...))
+	if m.zoom.level > 0 {
+		helpView += m.divDot().Render() + m.txtYellow().Render("🔍 "+m.zoom.String())
+	}
+	if m.hdState == hdLoading {
+		loading := "✨ loading HD image..."
+		if d := m.download; d.total > 0 {
+			loading += fmt.Sprintf(" %d%%", d.read*100/d.total)
+		}
+		helpView += m.divDot().Render() + m.txtYellow().Render(loading)
+	}
+	return m.viewOverImage(helpView)
+}
 
-	imageWidth, imageHeight := fitImage(image.Bounds().Dx(), image.Bounds().Dy(), totalWidth, totalHeight)
-	asciiImage := converter.Image2ASCIIString(image, &convert.Options{
-		Colored:     true,
-		FixedWidth:  imageWidth,
-		FixedHeight: imageHeight,
-	})
+// viewOverImage is the image over the whole screen, with line laid over the
+// bottom of it.
+func (m *Model) viewOverImage(line string) string {
+	totalWidth, totalHeight := m.fullscreenBox()
+	line = ansi.Truncate(line, m.Width, "…")
 
 	view := lipgloss.Place(
 		totalWidth, totalHeight, lipgloss.Center, lipgloss.Center,
-		asciiImage,
+		m.viewImage(totalWidth, totalHeight),
 	)
+	if m.RenderMode.inline() {
+		return lipgloss.JoinVertical(lipgloss.Left, view, line)
+	}
 
+	// lay the line over the start of the last one
 	viewLines := strings.Split(view, "\n")
 	lastLine := viewLines[len(viewLines)-1]
-	if len(lastLine) > len(helpView) {
-		lastLine = helpView + lastLine[len(helpView):]
-	}
-	viewLines[len(viewLines)-1] = lastLine
+	viewLines[len(viewLines)-1] = line + ansi.TruncateLeft(lastLine, ansi.StringWidth(line), "")
 	return strings.Join(viewLines, "\n")
 }
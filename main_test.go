@@ -0,0 +1,36 @@
+package airlockspace
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kamaln7/airlock.space/apod"
+)
+
+func TestStateWithoutImage(t *testing.T) {
+	tests := []struct {
+		name      string
+		mediaType string
+		url       string
+		want      State
+	}{
+		{"video", "video", "https://www.youtube.com/embed/abc", StateVideo},
+		{"other media", "other", "", StateImageError},
+		{"image without a url", "image", "", StateImageError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := testModel()
+			a, date := testAPOD(t, "2001-04-05")
+			a.MediaType, a.URL = tt.mediaType, tt.url
+			m.date = date
+			m.Update(apodMsg{date: date, apod: a})
+			if m.State != tt.want {
+				t.Errorf("State = %d, want %d", m.State, tt.want)
+			}
+			if tt.want == StateImageError && !errors.Is(m.imageErr, apod.ErrNoImage) {
+				t.Errorf("imageErr = %v, want %v", m.imageErr, apod.ErrNoImage)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package airlockspace
+
+import (
+	"image"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// helpZone is where a help entry ended up on screen, so that clicking it can
+// do what pressing its key would.
+type helpZone struct {
+	y, x0, x1 int // x1 is exclusive
+	binding   key.Binding
+}
+
+// helpZones lays the view out again to find where its help entries are.
+// It is only needed on a click, and doing it then keeps View from having to
+// record anything.
+func (m *Model) helpZones() []helpZone {
+	var shown [][]key.Binding
+	m.helpShown = &shown
+	view := m.view()
+	m.helpShown = nil
+	return findHelpZones(view, shown)
+}
+
+// findHelpZones locates the entries of every help line in helps within view.
+// A help line that was cut short can't be found, and isn't clickable.
+func findHelpZones(view string, helps [][]key.Binding) []helpZone {
+	lines := strings.Split(view, "\n")
+	var zones []helpZone
+	for _, bindings := range helps {
+		// the help as ShortHelpView lays it out, minus the styling
+		var text strings.Builder
+		var entries []helpZone
+		for i, b := range bindings {
+			if i > 0 {
+				text.WriteString(" • ")
+			}
+			x0 := ansi.StringWidth(text.String())
+			text.WriteString(b.Help().Key + " " + b.Help().Desc)
+			entries = append(entries, helpZone{x0: x0, x1: ansi.StringWidth(text.String()), binding: b})
+		}
+
+		for y, line := range lines {
+			plain := ansi.Strip(line)
+			i := strings.Index(plain, text.String())
+			if i < 0 {
+				continue
+			}
+			x := ansi.StringWidth(plain[:i])
+			for _, e := range entries {
+				zones = append(zones, helpZone{y: y, x0: x + e.x0, x1: x + e.x1, binding: e.binding})
+			}
+			break
+		}
+	}
+	return zones
+}
+
+// keyFor returns a key press that matches b, if b has a single-character
+// key to press.
+func keyFor(b key.Binding) (tea.KeyMsg, bool) {
+	for _, k := range b.Keys() {
+		if utf8.RuneCountInString(k) == 1 {
+			return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}, true
+		}
+	}
+	return tea.KeyMsg{}, false
+}
+
+// mouse handles clicks on help entries, wheel zooming and dragging to pan in
+// fullscreen. The explanation viewport handles its own wheel scrolling.
+func (m *Model) mouse(msg tea.MouseMsg) tea.Cmd {
+	switch {
+	case msg.Action == tea.MouseActionPress && msg.Button == tea.MouseButtonLeft:
+		for _, z := range m.helpZones() {
+			if msg.Y != z.y || msg.X < z.x0 || msg.X >= z.x1 {
+				continue
+			}
+			// panning and moving have no single key to stand for them
+			if z.binding.Help() == keyPan.Help() || z.binding.Help() == keyCalendarMove.Help() {
+				return nil
+			}
+			if k, ok := keyFor(z.binding); ok {
+				return m.keyPress(k)
+			}
+			return nil
+		}
+		if m.State == StateFullscreen && m.zoom.level > 0 {
+			m.dragging, m.dragAt = true, image.Pt(msg.X, msg.Y)
+		}
+	case msg.Action == tea.MouseActionMotion && m.dragging:
+		// the image follows the pointer, so the view moves the other way
+		width, height := m.fullscreenBox()
+		m.pan(float64(m.dragAt.X-msg.X)/float64(width), float64(m.dragAt.Y-msg.Y)/float64(height))
+		m.dragAt = image.Pt(msg.X, msg.Y)
+	case msg.Action == tea.MouseActionRelease:
+		m.dragging = false
+	case m.State == StateFullscreen && msg.Button == tea.MouseButtonWheelUp:
+		m.zoom = m.zoom.zoomBy(1)
+	case m.State == StateFullscreen && msg.Button == tea.MouseButtonWheelDown:
+		m.zoom = m.zoom.zoomBy(-1)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package airlockspace
+
+import (
+	"image/color"
+	"log/slog"
+	"strings"
+
+	"github.com/muesli/termenv"
+	"rsc.io/qr"
+)
+
+// qrQuietZone is the blank border around a QR code, in modules. The spec
+// asks for 4, but phones cope with less and terminals are small.
+const qrQuietZone = 2
+
+// viewQR renders a QR code of text with half blocks, two modules to a cell,
+// or returns "" if it doesn't fit in width x height cells.
+func (m *Model) viewQR(text string, width, height int) string {
+	code, err := qr.Encode(text, qr.M)
+	if err != nil {
+		slog.Warn("failed to encode QR code", "error", err)
+		return ""
+	}
+	size := code.Size + 2*qrQuietZone
+	if size > width || (size+1)/2 > height {
+		return ""
+	}
+	// the quiet zone has to be light like the light modules
+	dark := func(x, y int) bool {
+		x, y = x-qrQuietZone, y-qrQuietZone
+		return x >= 0 && y >= 0 && x < code.Size && y < code.Size && code.Black(x, y)
+	}
+
+	black, white := m.Profile.FromColor(color.Black), m.Profile.FromColor(color.White)
+	var s strings.Builder
+	for y := 0; y < size; y += 2 {
+		if y > 0 {
+			s.WriteString("\n")
+		}
+		if m.Profile == termenv.Ascii {
+			// no colors to set, so draw the light modules in the foreground
+			// color and hope it is lighter than the background
+			for x := range size {
+				top, bottom := !dark(x, y), y+1 < size && !dark(x, y+1)
+				switch {
+				case top && bottom:
+					s.WriteString("█")
+				case top:
+					s.WriteString("▀")
+				case bottom:
+					s.WriteString("▄")
+				default:
+					s.WriteString(" ")
+				}
+			}
+			continue
+		}
+
+		var last string
+		for x := range size {
+			fg, bg := white, white
+			if dark(x, y) {
+				fg = black
+			}
+			if y+1 < size && dark(x, y+1) {
+				bg = black
+			}
+			seq := sequence(fg, false) + sequence(bg, true)
+			if seq != last {
+				s.WriteString(seq)
+				last = seq
+			}
+			s.WriteString("▀")
+		}
+		s.WriteString(resetSequence)
+	}
+	return s.String()
+}
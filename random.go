@@ -0,0 +1,103 @@
+package airlockspace
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kamaln7/airlock.space/apod"
+)
+
+// maxHistory is how many days back can be gone back to.
+const maxHistory = 100
+
+// randomErrorFor is how long a failed random pick is shown for.
+const randomErrorFor = 5 * time.Second
+
+var (
+	keyRandom = key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "random"),
+	)
+	keyBack = key.NewBinding(
+		key.WithKeys("b"),
+		key.WithHelp("b", "back"),
+	)
+)
+
+type randomMsg struct {
+	apod *apod.APOD
+	err  error // why apod is nil, if it is
+}
+
+// randomErrExpiredMsg takes down err, unless a later pick failed since.
+type randomErrExpiredMsg struct {
+	err error
+}
+
+// loadRandom picks an APOD from a random day in the background.
+func (m *Model) loadRandom() tea.Cmd {
+	m.pickingRandom = true
+	m.State = StateLoading
+	return func() tea.Msg {
+		a, err := m.archive().Random(context.Background())
+		if err != nil {
+			slog.Warn("failed to get a random APOD", "error", err)
+		}
+		return randomMsg{apod: a, err: err}
+	}
+}
+
+// showRandom remembers the day being left for back and shows the random
+// pick like any other day. If there is no pick, the day shown stays and says
+// why for a while.
+func (m *Model) showRandom(msg randomMsg) tea.Cmd {
+	m.pickingRandom = false
+	a := msg.apod
+	if a == nil {
+		err := msg.err
+		if err == nil {
+			err = apod.ErrNotFound
+		}
+		m.randomErr = err
+		m.State = m.mainState()
+		return tea.Tick(randomErrorFor, func(time.Time) tea.Msg {
+			return randomErrExpiredMsg{err: err}
+		})
+	}
+
+	m.history = append(m.history, m.currentDate())
+	if len(m.history) > maxHistory {
+		m.history = m.history[1:]
+	}
+	date := apod.Day(a.ApodDate)
+	m.date = date
+	return func() tea.Msg {
+		return apodMsg{date: date, apod: a}
+	}
+}
+
+// back returns to the day that was shown before the last random pick.
+func (m *Model) back() tea.Cmd {
+	if len(m.history) == 0 {
+		return nil
+	}
+	date := m.history[len(m.history)-1]
+	m.history = m.history[:len(m.history)-1]
+	if date.Equal(m.currentDate()) {
+		return nil
+	}
+	return m.goTo(date)
+}
+
+// dayKeys are the keys for changing the day shown, including back if there
+// is anything to go back to.
+func (m *Model) dayKeys() []key.Binding {
+	keys := []key.Binding{keyPrev, keyNext, keyCalendar, keyRandom}
+	if len(m.history) > 0 {
+		keys = append(keys, keyBack)
+	}
+	return keys
+}
@@ -0,0 +1,30 @@
+package airlockspace
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/kamaln7/airlock.space/apod"
+)
+
+func TestRandomFailure(t *testing.T) {
+	m := testModel()
+	m.Archive = apod.New(apod.Fixtures(t.TempDir()))
+	a, date := testAPOD(t, "2001-05-06")
+	show(m, a, date, testImage(40, 30))
+
+	msg := m.loadRandom()()
+	m.Update(msg)
+	if m.State != StateAPOD || m.apod != a {
+		t.Errorf("a failed pick left state %d showing %v, want the day shown before", m.State, m.apod)
+	}
+	if !errors.Is(m.randomErr, apod.ErrNotFound) || !strings.Contains(m.View(), "no random pick") {
+		t.Errorf("the failure isn't shown: randomErr = %v", m.randomErr)
+	}
+
+	m.Update(randomErrExpiredMsg{err: m.randomErr})
+	if m.randomErr != nil {
+		t.Error("the failure is still shown after it expired")
+	}
+}
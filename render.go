@@ -0,0 +1,450 @@
+package airlockspace
+
+import (
+	"container/list"
+	"image"
+	"image/color"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/muesli/termenv"
+	"github.com/qeesung/image2ascii/convert"
+)
+
+// renderDebounce is how long a frame has to stay wanted before it is
+// rendered, so that dragging a window edge doesn't queue a render per column.
+const renderDebounce = 50 * time.Millisecond
+
+// RenderMode picks how the APOD image is drawn in the terminal.
+type RenderMode int
+
+const (
+	RenderASCII         RenderMode = iota // characters from a brightness ramp
+	RenderHalfBlock                       // "▀" with separate fg/bg colors
+	RenderBraille                         // 2x4 Braille dots, thresholded
+	RenderBrailleDither                   // 2x4 Braille dots, dithered
+	RenderKitty                           // kitty graphics protocol
+	RenderITerm2                          // iTerm2 inline images
+	RenderSixel                           // DEC sixel graphics
+)
+
+// renderModes are the modes every terminal can show, in the order keyRender
+// cycles through them.
+var renderModes = []RenderMode{RenderASCII, RenderHalfBlock, RenderBraille, RenderBrailleDither}
+
+// DefaultRenderMode is the mode that looks best on a terminal with the given
+// color profile and image protocols: a real image where possible, Braille
+// where there are no colors to carry the image, ASCII otherwise.
+func DefaultRenderMode(profile termenv.Profile, graphics []RenderMode) RenderMode {
+	if len(graphics) > 0 {
+		return graphics[0]
+	}
+	if profile == termenv.Ascii {
+		return RenderBrailleDither
+	}
+	return RenderASCII
+}
+
+func (r RenderMode) String() string {
+	switch r {
+	case RenderASCII:
+		return "ascii"
+	case RenderHalfBlock:
+		return "half-block"
+	case RenderBraille:
+		return "braille"
+	case RenderBrailleDither:
+		return "braille-dither"
+	case RenderKitty:
+		return "kitty"
+	case RenderITerm2:
+		return "iterm2"
+	case RenderSixel:
+		return "sixel"
+	}
+	return "unknown"
+}
+
+// ParseRenderMode parses a mode as named by String, e.g. from an environment
+// variable.
+func ParseRenderMode(s string) (RenderMode, bool) {
+	for _, mode := range slices.Concat(renderModes, graphicsModes) {
+		if strings.EqualFold(s, mode.String()) {
+			return mode, true
+		}
+	}
+	return 0, false
+}
+
+// frames is shared by every session, so the same APOD rendered at the same
+// size is only converted once no matter how many people are looking at it.
+var frames = newFrameCache(64 << 20)
+
+type frameKey struct {
+	date    string
+	width   int
+	height  int
+	mode    RenderMode
+	profile termenv.Profile
+	dither  Dither
+	aspect  float64         // cell width / height
+	cell    image.Point     // cell size in pixels, if known
+	crop    image.Rectangle // part of the image shown, empty for all of it
+	hd      bool            // of the HD image rather than the preview
+}
+
+// cellPixels is the pixel size of a cell, or fallback adjusted to the cell
+// aspect ratio if the terminal didn't tell.
+func (k frameKey) cellPixels(fallback image.Point) image.Point {
+	if k.cell.X > 0 && k.cell.Y > 0 {
+		return k.cell
+	}
+	return image.Pt(max(1, int(float64(fallback.Y)*k.aspect)), fallback.Y)
+}
+
+// resolution is how many image pixels a cell shows across and down.
+func (k frameKey) resolution() image.Point {
+	switch k.mode {
+	case RenderHalfBlock:
+		return image.Pt(1, 2)
+	case RenderBraille, RenderBrailleDither:
+		return image.Pt(2, 4)
+	case RenderKitty, RenderITerm2:
+		return k.cellPixels(inlineCellPixels)
+	case RenderSixel:
+		return k.cellPixels(sixelCellPixels)
+	}
+	return image.Pt(1, 1)
+}
+
+// frameCache is a size-bounded LRU of rendered frames.
+type frameCache struct {
+	mu       sync.Mutex
+	maxBytes int
+	size     int
+	lru      *list.List // of *frameEntry, most recently used first
+	entries  map[frameKey]*list.Element
+}
+
+type frameEntry struct {
+	key   frameKey
+	frame string
+}
+
+func newFrameCache(maxBytes int) *frameCache {
+	return &frameCache{
+		maxBytes: maxBytes,
+		lru:      list.New(),
+		entries:  map[frameKey]*list.Element{},
+	}
+}
+
+// peek returns the cached frame for key without rendering it.
+func (c *frameCache) peek(key frameKey) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(el)
+		return el.Value.(*frameEntry).frame, true
+	}
+	return "", false
+}
+
+// get returns the cached frame for key, rendering and storing it on a miss.
+func (c *frameCache) get(key frameKey, render func() string) string {
+	c.mu.Lock()
+	if el, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(el)
+		c.mu.Unlock()
+		return el.Value.(*frameEntry).frame
+	}
+	c.mu.Unlock()
+
+	// render without holding the lock; at worst two sessions render the same
+	// frame concurrently and the second one wins
+	frame := render()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(el)
+		return frame
+	}
+	c.entries[key] = c.lru.PushFront(&frameEntry{key: key, frame: frame})
+	c.size += len(frame)
+	for c.size > c.maxBytes && c.lru.Len() > 1 {
+		el := c.lru.Back()
+		entry := el.Value.(*frameEntry)
+		c.lru.Remove(el)
+		delete(c.entries, entry.key)
+		c.size -= len(entry.frame)
+	}
+	return frame
+}
+
+// renderImage draws img as described by key.
+func renderImage(img image.Image, key frameKey) string {
+	switch key.mode {
+	case RenderHalfBlock:
+		return renderHalfBlock(img, key)
+	case RenderBraille, RenderBrailleDither:
+		return renderBraille(img, key)
+	case RenderKitty, RenderITerm2, RenderSixel:
+		return renderInline(img, key)
+	}
+	return renderASCII(img, key)
+}
+
+// renderASCII converts img to characters from image2ascii's brightness ramp,
+// one pixel per cell. image2ascii would color them with 256-color sequences
+// regardless of what the terminal supports, so the colors are quantized here
+// instead.
+func renderASCII(img image.Image, key frameKey) string {
+	profile := key.profile
+	imageWidth, imageHeight := fitImage(img.Bounds().Dx(), img.Bounds().Dy(), key.width, key.height, key.aspect)
+	if imageWidth == 0 || imageHeight == 0 {
+		return ""
+	}
+	converter := convert.NewImageConverter()
+	pixels := converter.Image2CharPixelMatrix(img, &convert.Options{
+		FixedWidth:  imageWidth,
+		FixedHeight: imageHeight,
+	})
+
+	var colors [][]termenv.Color
+	if profile != termenv.Ascii {
+		tints := image.NewNRGBA(image.Rect(0, 0, imageWidth, imageHeight))
+		for y, line := range pixels {
+			for x, p := range line {
+				tints.SetNRGBA(x, y, color.NRGBA{p.R, p.G, p.B, p.A})
+			}
+		}
+		colors = quantize(tints, profile, key.dither)
+	}
+
+	var s strings.Builder
+	for y, line := range pixels {
+		if y > 0 {
+			s.WriteString("\n")
+		}
+		var last string
+		for x, p := range line {
+			if colors != nil {
+				seq := sequence(colors[y][x], false)
+				if seq != last {
+					s.WriteString(seq)
+					last = seq
+				}
+			}
+			s.WriteByte(p.Char)
+		}
+		if colors != nil {
+			s.WriteString(resetSequence)
+		}
+	}
+	return s.String()
+}
+
+// renderMsg asks for a frame to be rendered once the debounce has passed.
+type renderMsg struct {
+	key frameKey
+}
+
+// frameMsg carries a frame rendered in the background.
+type frameMsg struct {
+	key   frameKey
+	frame string
+}
+
+func (m *Model) frameKeyFor(width, height int) frameKey {
+	key := frameKey{
+		date:    m.apod.Date,
+		width:   width,
+		height:  height,
+		mode:    m.RenderMode,
+		profile: m.Profile,
+		dither:  m.Dither.resolve(m.Profile),
+		aspect:  m.cellAspect(),
+		cell:    m.CellSize,
+	}
+	if m.State == StateFullscreen && m.image != nil {
+		key.hd = m.hdImage != nil
+		key.crop = m.zoom.crop(m.imageFor(key).Bounds(), width, height, key.aspect)
+	}
+	return key
+}
+
+// imageFor is the image key is a frame of.
+func (m *Model) imageFor(key frameKey) image.Image {
+	if key.hd {
+		return m.hdImage
+	}
+	return m.image
+}
+
+// defaultCellAspect is the width / height of a cell in most fonts.
+const defaultCellAspect = 0.5
+
+// cellAspect is the width / height of a terminal cell: CellAspect if set,
+// otherwise worked out from CellSize if the terminal reported it, otherwise
+// defaultCellAspect.
+func (m *Model) cellAspect() float64 {
+	switch {
+	case m.CellAspect > 0:
+		return m.CellAspect
+	case m.CellSize.X > 0 && m.CellSize.Y > 0:
+		return float64(m.CellSize.X) / float64(m.CellSize.Y)
+	}
+	return defaultCellAspect
+}
+
+// imageBox is the size the image is shown at in the current state, if it is
+// shown at all.
+func (m *Model) imageBox() (width, height int, ok bool) {
+	if m.apod == nil || m.image == nil {
+		return 0, 0, false
+	}
+	switch {
+	case m.State == StateFullscreen || m.State == StateSlideshow:
+		width, height := m.fullscreenBox()
+		return width, height, true
+	case m.State == StateAPOD && m.imgOrExplanation:
+		_, _, width, height := m.imageLayout()
+		return width, height, true
+	}
+	return 0, 0, false
+}
+
+// requestFrame schedules a render of the frame the current state needs,
+// unless it is already rendered or on its way.
+func (m *Model) requestFrame() tea.Cmd {
+	width, height, ok := m.imageBox()
+	if !ok {
+		return nil
+	}
+	key := m.frameKeyFor(width, height)
+	if key == m.frameKey || key == m.pendingFrame {
+		return nil
+	}
+	if frame, ok := frames.peek(key); ok {
+		// whatever was pending is for a frame no longer wanted
+		m.frame, m.frameKey = frame, key
+		m.pendingFrame, m.pendingImage = frameKey{}, nil
+		return nil
+	}
+
+	m.pendingFrame, m.pendingImage = key, m.imageFor(key)
+	return tea.Tick(renderDebounce, func(time.Time) tea.Msg {
+		return renderMsg{key: key}
+	})
+}
+
+// renderFrame renders the frame for key in the background, unless the
+// session has moved on to a different size or image in the meantime. It
+// renders the image that was shown when the frame was asked for, since the
+// frame is cached under key for every session.
+func (m *Model) renderFrame(key frameKey) tea.Cmd {
+	if key != m.pendingFrame || m.pendingImage == nil {
+		return nil
+	}
+	img := m.pendingImage
+	return func() tea.Msg {
+		frame := frames.get(key, func() string {
+			return renderImage(cropImage(img, key.crop), key)
+		})
+		return frameMsg{key: key, frame: frame}
+	}
+}
+
+// inlineShown is the frame drawn by the terminal that the current view
+// shows, if any.
+func (m *Model) inlineShown() frameKey {
+	width, height, ok := m.imageBox()
+	if !ok || !m.RenderMode.inline() {
+		return frameKey{}
+	}
+	key := m.frameKeyFor(width, height)
+	if key != m.frameKey {
+		return frameKey{}
+	}
+	return key
+}
+
+// viewImage returns the image to show in a width x height box. Until the
+// frame for that size is rendered, the previous frame of the same image is
+// shown scaled to fit, or a placeholder if there is none.
+func (m *Model) viewImage(width, height int) string {
+	key := m.frameKeyFor(width, height)
+	if key == m.frameKey {
+		return m.frame
+	}
+	if frame, ok := frames.peek(key); ok {
+		return frame
+	}
+	// a frame drawn by the terminal can't be scaled, and one of another part
+	// of the image would show the wrong thing
+	if m.frame != "" && m.frameKey.date == key.date && m.frameKey.crop == key.crop &&
+		!key.mode.inline() && !m.frameKey.mode.inline() {
+		first, _, _ := strings.Cut(m.frame, "\n")
+		cols, rows := fitImage(ansi.StringWidth(first), countLines(m.frame), width, height, 1)
+		return scaleFrame(m.frame, cols, rows)
+	}
+	return m.txtYellow().Render("✨ rendering...")
+}
+
+// scaleFrame resizes a rendered frame to cols x rows cells by repeating or
+// dropping cells, for a rough idea of the image while the real frame
+// renders. Every escape sequence is kept, in order, so that the colors of
+// the cells that are left stay right.
+func scaleFrame(frame string, cols, rows int) string {
+	lines := strings.Split(frame, "\n")
+	out := make([]string, 0, rows)
+	for y := range rows {
+		cells, rest := frameCells(lines[y*len(lines)/rows])
+		var line strings.Builder
+		next := 0 // the first cell whose sequences haven't been written
+		for x := range cols {
+			if len(cells) == 0 {
+				break
+			}
+			i := x * len(cells) / cols
+			for ; next <= i; next++ {
+				line.WriteString(cells[next].seqs)
+			}
+			line.WriteString(cells[i].text)
+		}
+		for ; next < len(cells); next++ {
+			line.WriteString(cells[next].seqs)
+		}
+		line.WriteString(rest)
+		out = append(out, line.String())
+	}
+	return strings.Join(out, "\n")
+}
+
+// frameCell is a cell of a rendered line, with the escape sequences that
+// come before it.
+type frameCell struct {
+	seqs, text string
+}
+
+// frameCells splits line into cells, and the sequences after the last one.
+func frameCells(line string) (cells []frameCell, rest string) {
+	var seqs strings.Builder
+	var state byte
+	for line != "" {
+		seq, width, n, newState := ansi.DecodeSequence(line, state, nil)
+		state, line = newState, line[n:]
+		if width == 0 {
+			seqs.WriteString(seq)
+			continue
+		}
+		cells = append(cells, frameCell{seqs: seqs.String(), text: seq})
+		seqs.Reset()
+	}
+	return cells, seqs.String()
+}
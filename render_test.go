@@ -0,0 +1,120 @@
+package airlockspace
+
+import (
+	"image"
+	"testing"
+	"time"
+
+	"github.com/kamaln7/airlock.space/apod"
+	"github.com/peteretelej/nasa"
+)
+
+// testAPOD is an APOD with an image for day, which has to be a day no other
+// test renders, since frames are cached for the whole process.
+func testAPOD(t *testing.T, day string) (*apod.APOD, time.Time) {
+	t.Helper()
+	date, err := time.Parse(time.DateOnly, day)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &apod.APOD{Metadata: &apod.Metadata{
+		Image:     nasa.Image{Date: day, ApodDate: date, URL: "https://example.com/" + day + ".png"},
+		MediaType: "image",
+	}}, date
+}
+
+// testImage is a w x h gradient, so that frames of it aren't blank.
+func testImage(w, h int) image.Image {
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	for i := range img.Pix {
+		img.Pix[i] = uint8(i)
+	}
+	return img
+}
+
+// show takes m to a, with img as its image, the way loading it would.
+func show(m *Model, a *apod.APOD, date time.Time, img image.Image) {
+	m.date = date
+	m.Update(apodMsg{date: date, apod: a})
+	m.Update(imageMsg{apod: a, image: img})
+}
+
+func testModel() *Model {
+	return &Model{Width: 80, Height: 24, imgOrExplanation: true}
+}
+
+func TestRenderFrameAfterNavigation(t *testing.T) {
+	m := testModel()
+	a, date := testAPOD(t, "2001-02-03")
+	show(m, a, date, testImage(40, 30))
+	key := m.pendingFrame
+	if key == (frameKey{}) {
+		t.Fatal("no frame was asked for")
+	}
+
+	// a video day comes in before the debounce is up
+	video, next := testAPOD(t, "2001-02-04")
+	video.MediaType, video.URL = "video", ""
+	m.date = next
+	m.Update(apodMsg{date: next, apod: video})
+	if cmd := m.renderFrame(key); cmd != nil {
+		t.Error("the frame of a day no longer shown is still rendered")
+	}
+}
+
+func TestRenderFrameCachedElsewhere(t *testing.T) {
+	m := testModel()
+	a, dateA := testAPOD(t, "2001-03-04")
+	b, dateB := testAPOD(t, "2001-03-05")
+	imgA, imgB := testImage(40, 30), testImage(30, 40)
+
+	// b's frame is in the cache from an earlier visit
+	show(m, b, dateB, imgB)
+	keyB := m.pendingFrame
+	m.Update(m.renderFrame(keyB)())
+
+	show(m, a, dateA, imgA)
+	keyA := m.pendingFrame
+	show(m, b, dateB, imgB)
+	if m.frameKey != keyB {
+		t.Fatal("b's cached frame isn't shown")
+	}
+	if cmd := m.renderFrame(keyA); cmd != nil {
+		if msg := cmd().(frameMsg); msg.frame == renderImage(imgB, keyA) {
+			t.Error("a's frame was rendered from b's image")
+		}
+	}
+}
+
+func TestScaleFrame(t *testing.T) {
+	const red, green, blue, reset = "\x1b[31m", "\x1b[32m", "\x1b[34m", "\x1b[0m"
+	frame := red + "ab" + green + "cd" + reset + "\n" + blue + "efgh" + reset
+	tests := []struct {
+		name       string
+		cols, rows int
+		want       string
+	}{
+		{"same size", 4, 2, frame},
+		{"down", 2, 1, red + "a" + green + "c" + reset},
+		{"up", 8, 3, red + "aabb" + green + "ccdd" + reset + "\n" +
+			red + "aabb" + green + "ccdd" + reset + "\n" +
+			blue + "eeffgghh" + reset},
+		// the colors of dropped cells still have to be set and reset
+		{"one cell", 1, 1, red + "a" + green + reset},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := scaleFrame(frame, tt.cols, tt.rows); got != tt.want {
+				t.Errorf("scaleFrame(%d, %d) = %q, want %q", tt.cols, tt.rows, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFrameCells(t *testing.T) {
+	cells, rest := frameCells("\x1b[1mé世\x1b[0m")
+	want := []frameCell{{seqs: "\x1b[1m", text: "é"}, {text: "世"}}
+	if len(cells) != len(want) || cells[0] != want[0] || cells[1] != want[1] || rest != "\x1b[0m" {
+		t.Errorf("frameCells() = %q, %q; want %q, %q", cells, rest, want, "\x1b[0m")
+	}
+}
@@ -0,0 +1,193 @@
+package airlockspace
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"slices"
+	"strings"
+
+	"github.com/nfnt/resize"
+)
+
+// sixelCellPixels is the cell size sixel images are sized for when the
+// terminal doesn't report it. Sixels are drawn pixel for pixel, so an image
+// sized for cells bigger than the real ones would spill out of its box; this
+// errs on the small side.
+var sixelCellPixels = image.Pt(8, 16)
+
+// sixelMaxColors is the palette size; VT340s had 16 registers, but every
+// terminal implementing sixel today has at least 256.
+const sixelMaxColors = 256
+
+// renderSixel draws img as sixels, laid out like renderITerm2.
+func renderSixel(img image.Image, key frameKey) string {
+	cols, rows := fitImage(img.Bounds().Dx(), img.Bounds().Dy(), key.width, key.height-1, key.aspect)
+	if cols == 0 || rows == 0 {
+		return ""
+	}
+
+	cell := key.cellPixels(sixelCellPixels)
+	imageWidth, imageHeight := fitImage(img.Bounds().Dx(), img.Bounds().Dy(), cols*cell.X, rows*cell.Y, 1)
+	scaled := resize.Resize(uint(imageWidth), uint(imageHeight), img, resize.Bilinear)
+	return anchored(cols, rows, encodeSixel(scaled))
+}
+
+// encodeSixel encodes img as a sixel DCS sequence: a palette of up to
+// sixelMaxColors colors picked by median cut, then the image in bands of six
+// rows, each band one run-length encoded line per color used in it.
+func encodeSixel(img image.Image) string {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	palette := medianCut(img, sixelMaxColors)
+
+	var s strings.Builder
+	// P2=1 leaves pixels no color is drawn in alone rather than filling them
+	// with the background
+	s.WriteString("\x1bP0;1;0q")
+	fmt.Fprintf(&s, "\"1;1;%d;%d", width, height)
+	for i, c := range palette {
+		r, g, b, _ := c.RGBA()
+		fmt.Fprintf(&s, "#%d;2;%d;%d;%d", i, r*100/0xffff, g*100/0xffff, b*100/0xffff)
+	}
+
+	indices := paletteIndices(img, palette)
+	bands := make([][]byte, len(palette)) // sixel bits per column, per color
+	for top := 0; top < height; top += 6 {
+		if top > 0 {
+			s.WriteString("-")
+		}
+		var used []int
+		for dy := 0; dy < 6 && top+dy < height; dy++ {
+			for x := range width {
+				idx := indices[(top+dy)*width+x]
+				if bands[idx] == nil {
+					bands[idx] = make([]byte, width)
+					used = append(used, int(idx))
+				}
+				bands[idx][x] |= 1 << dy
+			}
+		}
+
+		slices.Sort(used)
+		for i, idx := range used {
+			if i > 0 {
+				s.WriteString("$")
+			}
+			fmt.Fprintf(&s, "#%d", idx)
+			writeSixelRuns(&s, bands[idx])
+			bands[idx] = nil
+		}
+	}
+	s.WriteString("\x1b\\")
+	return s.String()
+}
+
+// writeSixelRuns writes a band's worth of sixels, collapsing repeats into
+// "!<count><sixel>".
+func writeSixelRuns(s *strings.Builder, bits []byte) {
+	for x := 0; x < len(bits); {
+		run := 1
+		for x+run < len(bits) && bits[x+run] == bits[x] {
+			run++
+		}
+		char := byte('?' + bits[x])
+		if run > 3 {
+			fmt.Fprintf(s, "!%d%c", run, char)
+		} else {
+			for range run {
+				s.WriteByte(char)
+			}
+		}
+		x += run
+	}
+}
+
+// medianCut picks up to n colors representing img by repeatedly splitting
+// the box of colors with the widest channel range at its median.
+func medianCut(img image.Image, n int) []color.Color {
+	bounds := img.Bounds()
+	pixels := make([][3]uint8, 0, bounds.Dx()*bounds.Dy())
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, _ := img.At(x, y).RGBA()
+			pixels = append(pixels, [3]uint8{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8)})
+		}
+	}
+	if len(pixels) == 0 {
+		return []color.Color{color.Black}
+	}
+
+	boxes := [][][3]uint8{pixels}
+	for len(boxes) < n {
+		// split the box whose widest channel is widest
+		best, bestChannel, bestRange := -1, 0, 0
+		for i, box := range boxes {
+			if len(box) < 2 {
+				continue
+			}
+			channel, rng := widestChannel(box)
+			if rng > bestRange {
+				best, bestChannel, bestRange = i, channel, rng
+			}
+		}
+		if best < 0 {
+			break
+		}
+
+		box := boxes[best]
+		slices.SortFunc(box, func(a, b [3]uint8) int {
+			return int(a[bestChannel]) - int(b[bestChannel])
+		})
+		mid := len(box) / 2
+		boxes[best] = box[:mid]
+		boxes = append(boxes, box[mid:])
+	}
+
+	palette := make([]color.Color, len(boxes))
+	for i, box := range boxes {
+		var r, g, b int
+		for _, p := range box {
+			r, g, b = r+int(p[0]), g+int(p[1]), b+int(p[2])
+		}
+		palette[i] = color.RGBA{uint8(r / len(box)), uint8(g / len(box)), uint8(b / len(box)), 0xff}
+	}
+	return palette
+}
+
+func widestChannel(box [][3]uint8) (channel, rng int) {
+	lo := [3]uint8{255, 255, 255}
+	var hi [3]uint8
+	for _, p := range box {
+		for c := range 3 {
+			lo[c], hi[c] = min(lo[c], p[c]), max(hi[c], p[c])
+		}
+	}
+	for c := range 3 {
+		if r := int(hi[c]) - int(lo[c]); r > rng {
+			channel, rng = c, r
+		}
+	}
+	return channel, rng
+}
+
+// paletteIndices maps every pixel of img, row by row, to the nearest color
+// in palette.
+func paletteIndices(img image.Image, palette []color.Color) []uint8 {
+	bounds := img.Bounds()
+	indices := make([]uint8, 0, bounds.Dx()*bounds.Dy())
+	nearest := map[color.RGBA]uint8{}
+	p := color.Palette(palette)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)
+			idx, ok := nearest[c]
+			if !ok {
+				idx = uint8(p.Index(c))
+				nearest[c] = idx
+			}
+			indices = append(indices, idx)
+		}
+	}
+	return indices
+}
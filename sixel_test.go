@@ -0,0 +1,49 @@
+package airlockspace
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+func TestWriteSixelRuns(t *testing.T) {
+	tests := []struct {
+		bits []byte
+		want string
+	}{
+		{nil, ""},
+		{[]byte{0, 1, 63}, "?@~"},
+		{[]byte{1, 1, 1}, "@@@"}, // too short to be worth a repeat
+		{[]byte{1, 1, 1, 1, 0}, "!4@?"},
+		{[]byte{2, 63, 63, 63, 63, 63, 2}, "A!5~A"},
+	}
+	for _, tt := range tests {
+		var s strings.Builder
+		writeSixelRuns(&s, tt.bits)
+		if got := s.String(); got != tt.want {
+			t.Errorf("writeSixelRuns(%v) = %q, want %q", tt.bits, got, tt.want)
+		}
+	}
+}
+
+func TestEncodeSixel(t *testing.T) {
+	// red on top of blue, the blue starting half way into the first band
+	img := image.NewRGBA(image.Rect(0, 0, 5, 8))
+	for y := range 8 {
+		c := color.RGBA{R: 255, A: 255}
+		if y >= 3 {
+			c = color.RGBA{B: 255, A: 255}
+		}
+		for x := range 5 {
+			img.Set(x, y, c)
+		}
+	}
+	palette := "#0;2;0;0;100#1;2;0;0;100#2;2;100;0;0#3;2;100;0;0"
+	// in the first band, blue is in rows 3-5 and red in 0-2; the second band
+	// is two rows of blue
+	want := "\x1bP0;1;0q\"1;1;5;8" + palette + "#0!5w$#2!5F-#0!5B\x1b\\"
+	if got := encodeSixel(img); got != want {
+		t.Errorf("encodeSixel() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,253 @@
+package airlockspace
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kamaln7/airlock.space/apod"
+)
+
+// SlideshowOrder is which APODs a slideshow goes through.
+type SlideshowOrder int
+
+const (
+	SlideshowRecent SlideshowOrder = iota // a day back at a time, see slideshowDays
+	SlideshowRandom                       // random days from the archive
+)
+
+var slideshowOrders = []SlideshowOrder{SlideshowRecent, SlideshowRandom}
+
+func (o SlideshowOrder) String() string {
+	switch o {
+	case SlideshowRecent:
+		return "recent"
+	case SlideshowRandom:
+		return "random"
+	}
+	return "unknown"
+}
+
+// ParseSlideshowOrder parses an order as named by String, e.g. from a flag.
+func ParseSlideshowOrder(s string) (SlideshowOrder, bool) {
+	for _, o := range slideshowOrders {
+		if strings.EqualFold(s, o.String()) {
+			return o, true
+		}
+	}
+	return 0, false
+}
+
+// defaultSlideshowInterval is how long a slide is shown for if
+// SlideshowInterval isn't set.
+const defaultSlideshowInterval = 30 * time.Second
+
+// slideshowDays is how far back SlideshowRecent goes before starting over
+// from the latest day, so that an unattended display doesn't work its way
+// through the whole archive.
+const slideshowDays = 30
+
+// maxSlideTries is how many days a prefetch goes through looking for one with
+// an image before giving up until the next slide.
+const maxSlideTries = 10
+
+// slideshow is the state of StateSlideshow.
+type slideshow struct {
+	gen      int        // bumped to drop ticks that are out of date
+	paused   bool       // by a key press, until resumed
+	next     *apod.APOD // prefetched, with its image, for the next tick
+	fetching bool       // whether a slideMsg is on its way
+	due      bool       // the tick came before next was ready
+}
+
+var (
+	keySlideshow = key.NewBinding(
+		key.WithKeys("s"),
+		key.WithHelp("s", "slideshow"),
+	)
+	keyStopSlideshow = key.NewBinding(
+		key.WithKeys("s", "esc"),
+		key.WithHelp("s", "stop"),
+	)
+	keyPause = key.NewBinding(
+		key.WithKeys(" "),
+		key.WithHelp("space", "pause"),
+	)
+)
+
+type slideTickMsg struct {
+	gen int
+}
+
+type slideMsg struct {
+	after time.Time // the day the slide is next after
+	apod  *apod.APOD
+}
+
+// startSlideshow switches to StateSlideshow, starting from the day shown.
+// If it hasn't loaded yet, which day that is isn't known, so the first slide
+// is looked for on the first tick instead.
+func (m *Model) startSlideshow() tea.Cmd {
+	m.State = StateSlideshow
+	m.slideshow = slideshow{gen: m.slideshow.gen + 1}
+	if m.apod == nil {
+		return m.tickSlideshow()
+	}
+	return tea.Batch(m.prefetchSlide(apod.Day(m.apod.ApodDate)), m.tickSlideshow())
+}
+
+// stopSlideshow leaves the slide shown as the day shown.
+func (m *Model) stopSlideshow() {
+	m.slideshow = slideshow{gen: m.slideshow.gen + 1}
+	m.State = m.mainState()
+}
+
+// slideshowKey handles key presses in StateSlideshow. Anything but resuming
+// or stopping pauses, so that whoever is at the keyboard gets a look.
+func (m *Model) slideshowKey(msg tea.KeyMsg) tea.Cmd {
+	switch {
+	case key.Matches(msg, keyStopSlideshow):
+		m.stopSlideshow()
+	case key.Matches(msg, keyPause) && m.slideshow.paused:
+		m.slideshow.paused, m.slideshow.due = false, false
+		return m.tickSlideshow()
+	default:
+		m.slideshow.paused = true
+	}
+	return nil
+}
+
+// tickSlideshow schedules the next slide, replacing any tick scheduled
+// before.
+func (m *Model) tickSlideshow() tea.Cmd {
+	m.slideshow.gen++
+	gen := m.slideshow.gen
+	interval := m.SlideshowInterval
+	if interval <= 0 {
+		interval = defaultSlideshowInterval
+	}
+	return tea.Tick(interval, func(time.Time) tea.Msg {
+		return slideTickMsg{gen: gen}
+	})
+}
+
+// prefetchSlide looks for the slide to show after date in the background,
+// along with its image, skipping days that have none.
+func (m *Model) prefetchSlide(date time.Time) tea.Cmd {
+	m.slideshow.fetching = true
+	archive, order := m.archive(), m.SlideshowOrder
+	return func() tea.Msg {
+		ctx := context.Background()
+		latest := apod.Day(time.Now())
+		oldest := latest.AddDate(0, 0, -slideshowDays)
+		day := date
+		for range maxSlideTries {
+			var a *apod.APOD
+			var err error
+			if order == SlideshowRandom {
+				a, err = archive.Random(ctx)
+			} else {
+				day = day.AddDate(0, 0, -1)
+				if day.Before(oldest) {
+					day = latest
+				}
+				a, err = archive.ForDate(ctx, day)
+			}
+			if err != nil {
+				slog.Warn("failed to prefetch slide", "error", err)
+				continue
+			}
+			if !a.HasImage() {
+				continue
+			}
+			if _, err := a.ImagePreview(); err != nil {
+				slog.Warn("failed to prefetch slide image", "date", a.Date, "error", err)
+				continue
+			}
+			return slideMsg{after: date, apod: a}
+		}
+		return slideMsg{after: date}
+	}
+}
+
+// slideTick shows the next slide if it is ready, or as soon as it is.
+func (m *Model) slideTick(msg slideTickMsg) tea.Cmd {
+	if m.State != StateSlideshow || m.slideshow.paused || msg.gen != m.slideshow.gen {
+		return nil
+	}
+	return m.nextSlide()
+}
+
+// slideReady takes a prefetched slide, showing it right away if its tick has
+// come already or the slide shown has no image.
+func (m *Model) slideReady(msg slideMsg) tea.Cmd {
+	if m.State != StateSlideshow {
+		return nil
+	}
+	m.slideshow.fetching = false
+	if !msg.after.Equal(m.currentDate()) {
+		// the day shown changed under it, so look again from there
+		if m.slideshow.due {
+			return m.prefetchSlide(m.currentDate())
+		}
+		return nil
+	}
+	if msg.apod == nil {
+		// nothing to show this time around; try again on the next tick
+		if m.slideshow.due {
+			return m.tickSlideshow()
+		}
+		return nil
+	}
+	m.slideshow.next = msg.apod
+	noImage := m.apod != nil && !m.apod.HasImage()
+	if !m.slideshow.paused && (m.slideshow.due || noImage) {
+		return m.nextSlide()
+	}
+	return nil
+}
+
+// nextSlide shows the prefetched slide and starts on the one after it.
+func (m *Model) nextSlide() tea.Cmd {
+	a := m.slideshow.next
+	if a == nil {
+		m.slideshow.due = true
+		if !m.slideshow.fetching {
+			return m.prefetchSlide(m.currentDate())
+		}
+		return nil
+	}
+	m.slideshow.next, m.slideshow.due = nil, false
+
+	date := apod.Day(a.ApodDate)
+	m.date = date
+	return tea.Batch(
+		func() tea.Msg {
+			return apodMsg{date: date, apod: a}
+		},
+		m.prefetchSlide(date),
+		m.tickSlideshow(),
+	)
+}
+
+// viewSlideshow is the slide in fullscreen with its title laid over it.
+func (m *Model) viewSlideshow() string {
+	if m.image == nil {
+		return m.viewLoading()
+	}
+
+	pause := keyPause
+	var status string
+	if m.slideshow.paused {
+		pause.SetHelp("space", "resume")
+		status = m.divDot().Render() + m.txtYellow().Render("⏸ paused")
+	}
+	title := m.txtYellow().Bold(true).Render(m.apod.Title) +
+		m.divDot().Render() + m.txtMuted().Render(m.currentDate().Format(time.DateOnly)) +
+		status + m.divDot().Render() +
+		strings.TrimSpace(m.viewHelp(pause, keyStopSlideshow, keyQuit))
+	return m.viewOverImage(title)
+}
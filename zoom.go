@@ -0,0 +1,97 @@
+package airlockspace
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+)
+
+// zoomStep is how much each zoom level magnifies the image.
+const zoomStep = 1.5
+
+// maxZoomLevel is the deepest zoom, about 25x.
+const maxZoomLevel = 8
+
+// panStep is how far a pan moves, as a fraction of what is on screen.
+const panStep = 0.25
+
+// zoom is the part of the image fullscreen shows.
+type zoom struct {
+	level int     // 0 shows the whole image
+	x, y  float64 // center of the view, as a fraction of the image size
+}
+
+func (z zoom) scale() float64 {
+	return math.Pow(zoomStep, float64(z.level))
+}
+
+func (z zoom) String() string {
+	return fmt.Sprintf("%.3g×", z.scale())
+}
+
+// zoomBy zooms in by levels, or out if levels is negative, keeping the
+// center where it is.
+func (z zoom) zoomBy(levels int) zoom {
+	if z.level == 0 {
+		z.x, z.y = 0.5, 0.5
+	}
+	z.level = min(max(z.level+levels, 0), maxZoomLevel)
+	return z
+}
+
+// crop is the part of an image with bounds shown in a width x height box of
+// cells that are aspect times as wide as they are tall. Zoomed in, the crop
+// fills as much of the box as the image allows; not zoomed in, it is the
+// empty rectangle, meaning the whole image.
+func (z zoom) crop(bounds image.Rectangle, width, height int, aspect float64) image.Rectangle {
+	if z.level == 0 {
+		return image.Rectangle{}
+	}
+	imageWidth, imageHeight := float64(bounds.Dx()), float64(bounds.Dy())
+	cols, _ := fitImage(bounds.Dx(), bounds.Dy(), width, height, aspect)
+	if cols == 0 {
+		return image.Rectangle{}
+	}
+
+	// image pixels per column, and the size of the box in those
+	colPixels := imageWidth / float64(cols) / z.scale()
+	w := min(imageWidth, float64(width)*colPixels)
+	h := min(imageHeight, float64(height)*colPixels/aspect)
+
+	// keep the crop within the image
+	x := min(max(z.x*imageWidth, w/2), imageWidth-w/2)
+	y := min(max(z.y*imageHeight, h/2), imageHeight-h/2)
+
+	crop := image.Rect(
+		int(math.Round(x-w/2)), int(math.Round(y-h/2)),
+		int(math.Round(x+w/2)), int(math.Round(y+h/2)),
+	)
+	return crop.Add(bounds.Min).Intersect(bounds)
+}
+
+// pan moves the view by dx, dy times its own size, and keeps it within the
+// image given the crop it currently shows of an image with bounds.
+func (z zoom) pan(dx, dy float64, bounds, crop image.Rectangle) zoom {
+	if z.level == 0 || crop.Empty() {
+		return z
+	}
+	imageWidth, imageHeight := float64(bounds.Dx()), float64(bounds.Dy())
+	w, h := float64(crop.Dx()), float64(crop.Dy())
+	x := float64(crop.Min.X-bounds.Min.X) + w/2 + dx*w
+	y := float64(crop.Min.Y-bounds.Min.Y) + h/2 + dy*h
+	z.x = min(max(x, w/2), imageWidth-w/2) / imageWidth
+	z.y = min(max(y, h/2), imageHeight-h/2) / imageHeight
+	return z
+}
+
+// cropImage copies the crop of img to an image of its own, or returns img if
+// crop is empty.
+func cropImage(img image.Image, crop image.Rectangle) image.Image {
+	if crop.Empty() {
+		return img
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, crop.Dx(), crop.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, crop.Min, draw.Src)
+	return dst
+}